@@ -0,0 +1,214 @@
+// Package agent caches vault encoding keys in memory for a limited time and
+// serves them to the secret CLI over a Unix socket, like ssh-agent does for
+// private keys, so the key only has to be typed once per session.
+//
+// The agent holds the encoding key itself rather than the key derived
+// from it: every save seals the vault with a fresh salt, so a derived key
+// would stop opening the vault after its next change. The CLI only hands
+// it keys that have opened their vault.
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long the agent keeps a key unless told otherwise.
+const DefaultTTL = 15 * time.Minute
+
+var dialTimeout = time.Second
+
+type request struct {
+	Op    string        `json:"op"`
+	Vault string        `json:"vault,omitempty"`
+	Key   string        `json:"key,omitempty"`
+	TTL   time.Duration `json:"ttl,omitempty"`
+}
+
+type response struct {
+	Key   string `json:"key,omitempty"`
+	Found bool   `json:"found,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Server holds encoding keys by vault path until their TTL runs out.
+type Server struct {
+	ttl      time.Duration
+	mutex    sync.Mutex
+	keys     map[string]*time.Timer
+	values   map[string]string
+	listener net.Listener
+}
+
+// NewServer returns a server that keeps keys for ttl by default.
+func NewServer(ttl time.Duration) *Server {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Server{
+		ttl:    ttl,
+		keys:   make(map[string]*time.Timer),
+		values: make(map[string]string),
+	}
+}
+
+// Listen listens on a Unix socket at path that only the current user can
+// connect to. A stale socket left by an agent that died is removed first.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return nil, errors.New("agent: another agent is already listening on " + path)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, errors.New("agent: " + path + " exists and is not a socket")
+		}
+		os.Remove(path)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve answers requests on l until a stop request arrives or l is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.mutex.Lock()
+	s.listener = l
+	s.mutex.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.clear()
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops Serve and forgets every key.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	l := s.listener
+	s.mutex.Unlock()
+	s.clear()
+	if l == nil {
+		return nil
+	}
+	return l.Close()
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	var req request
+	var res response
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	switch req.Op {
+	case "get":
+		res.Key, res.Found = s.get(req.Vault)
+	case "put":
+		s.put(req.Vault, req.Key, req.TTL)
+	case "clear":
+		s.clear()
+	case "stop":
+		defer s.Close()
+	default:
+		res.Error = "unknown operation " + req.Op
+	}
+	json.NewEncoder(conn).Encode(res)
+}
+
+func (s *Server) get(vault string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	key, ok := s.values[vault]
+	return key, ok
+}
+
+func (s *Server) put(vault, key string, ttl time.Duration) {
+	if ttl <= 0 || ttl > s.ttl {
+		ttl = s.ttl
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if t, ok := s.keys[vault]; ok {
+		t.Stop()
+	}
+	s.values[vault] = key
+	s.keys[vault] = time.AfterFunc(ttl, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.values, vault)
+		delete(s.keys, vault)
+	})
+}
+
+func (s *Server) clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for vault, t := range s.keys {
+		t.Stop()
+		delete(s.keys, vault)
+		delete(s.values, vault)
+	}
+}
+
+func call(socket string, req request) (response, error) {
+	var res response
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		return res, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return res, err
+	}
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return res, err
+	}
+	if res.Error != "" {
+		return res, errors.New("agent: " + res.Error)
+	}
+	return res, nil
+}
+
+// Get asks the agent at socket for the key of vault.
+func Get(socket, vault string) (string, bool, error) {
+	res, err := call(socket, request{Op: "get", Vault: vault})
+	return res.Key, res.Found, err
+}
+
+// Put gives the agent at socket the key of vault to hold for ttl, or the
+// agent's own TTL if ttl is zero or longer.
+func Put(socket, vault, key string, ttl time.Duration) error {
+	_, err := call(socket, request{Op: "put", Vault: vault, Key: key, TTL: ttl})
+	return err
+}
+
+// Clear makes the agent at socket forget every key.
+func Clear(socket string) error {
+	_, err := call(socket, request{Op: "clear"})
+	return err
+}
+
+// Stop makes the agent at socket forget every key and exit.
+func Stop(socket string) error {
+	_, err := call(socket, request{Op: "stop"})
+	return err
+}
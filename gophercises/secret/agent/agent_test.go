@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startAgent(t *testing.T, ttl time.Duration) (string, *Server, chan error) {
+	dir, err := ioutil.TempDir("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "agent.sock")
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(ttl)
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+	return socket, s, done
+}
+
+func TestAgent(t *testing.T) {
+	socket, s, done := startAgent(t, time.Minute)
+	defer os.RemoveAll(filepath.Dir(socket))
+	defer s.Close()
+
+	t.Run("it only lets the owner connect", func(t *testing.T) {
+		info, err := os.Stat(socket)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("it holds keys per vault", func(t *testing.T) {
+		assert.Nil(t, Put(socket, "/a/.secrets", "akey", 0))
+		key, ok, err := Get(socket, "/a/.secrets")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "akey", key)
+
+		_, ok, err = Get(socket, "/b/.secrets")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("it forgets keys after their ttl", func(t *testing.T) {
+		assert.Nil(t, Put(socket, "/short", "key", 20*time.Millisecond))
+		time.Sleep(100 * time.Millisecond)
+		_, ok, _ := Get(socket, "/short")
+		assert.False(t, ok)
+	})
+
+	t.Run("it forgets every key on clear", func(t *testing.T) {
+		assert.Nil(t, Clear(socket))
+		_, ok, _ := Get(socket, "/a/.secrets")
+		assert.False(t, ok)
+	})
+
+	t.Run("it refuses a second agent on the same socket", func(t *testing.T) {
+		_, err := Listen(socket)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it stops on request", func(t *testing.T) {
+		assert.Nil(t, Stop(socket))
+		select {
+		case err := <-done:
+			assert.Nil(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("agent did not stop")
+		}
+		_, _, err := Get(socket, "/a/.secrets")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestServerTTL(t *testing.T) {
+	s := NewServer(0)
+	assert.Equal(t, DefaultTTL, s.ttl)
+
+	s = NewServer(time.Minute)
+	s.put("/vault", "key", time.Hour)
+	defer s.clear()
+	key, ok := s.get("/vault")
+	assert.True(t, ok)
+	assert.Equal(t, "key", key)
+}
@@ -0,0 +1,190 @@
+// Package api serves a secret.Vault over a local HTTP/JSON API.
+//
+// Every request needs an "Authorization: Bearer <token>" header with a
+// client token created by `secret token create`. Reading needs the read
+// scope, setting and deleting need the write scope. What clients do is
+// recorded in the audit log of the vault under the name of their token,
+// and so are refused requests, under the ID of an unknown token or as
+// "anonymous" without one.
+//
+//	GET    /v1/secrets        list keys
+//	GET    /v1/secrets/{key}  get a value
+//	PUT    /v1/secrets/{key}  set a value, body {"value": "..."}
+//	DELETE /v1/secrets/{key}  delete a value
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"gophercises/secret"
+)
+
+const prefix = "/v1/secrets"
+
+// Value is the JSON body of a single secret.
+type Value struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+// Keys is the JSON body of a key listing.
+type Keys struct {
+	Keys []string `json:"keys"`
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+type handler struct {
+	vault *secret.Vault
+	// mutex serializes requests, as the vault records the client of the
+	// request being served as the user in its audit log
+	mutex sync.Mutex
+}
+
+// NewHandler returns the API for v. Nothing else should use v while it
+// is served.
+func NewHandler(v *secret.Vault) http.Handler {
+	return &handler{vault: v}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := ""
+	switch {
+	case r.URL.Path == prefix:
+	case strings.HasPrefix(r.URL.Path, prefix+"/"):
+		key = strings.TrimPrefix(r.URL.Path, prefix+"/")
+		if key == "" {
+			respondError(w, http.StatusNotFound, "not found")
+			return
+		}
+	default:
+		respondError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	op, scope := "", secret.ScopeRead
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		op = "list"
+	case r.Method == http.MethodGet:
+		op = "get"
+	case r.Method == http.MethodPut && key != "":
+		op, scope = "set", secret.ScopeWrite
+	case r.Method == http.MethodDelete && key != "":
+		op, scope = "remove", secret.ScopeWrite
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.serve(w, r, op, key, scope)
+}
+
+// serve authenticates the client of r and performs op on key for it.
+// Refused requests are recorded in the audit log too, under the token
+// name, the ID of an unknown token, or "anonymous".
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, op, key, scope string) {
+	defer h.vault.SetAuditUser("")
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		h.deny(w, http.StatusUnauthorized, "anonymous", op, key, errors.New("missing bearer token"))
+		return
+	}
+	client, t, err := h.vault.Authenticate(token)
+	if err == secret.ErrUnauthorized {
+		h.deny(w, http.StatusUnauthorized, "token "+secret.TokenID(token), op, key, errors.New("invalid token"))
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "vault unavailable")
+		return
+	}
+	if !t.Allows(scope) {
+		h.deny(w, http.StatusForbidden, client, op, key, errors.New("token lacks the "+scope+" scope"))
+		return
+	}
+	h.vault.SetAuditUser(client)
+	h.perform(w, r, op, key)
+}
+
+// deny records op on key as refused for client with err and responds
+// with status.
+func (h *handler) deny(w http.ResponseWriter, status int, client, op, key string, err error) {
+	h.vault.SetAuditUser(client)
+	if rerr := h.vault.RecordDenied(op, key, err); rerr != nil {
+		respondError(w, http.StatusInternalServerError, "vault unavailable")
+		return
+	}
+	respondError(w, status, err.Error())
+}
+
+// perform does op on key and responds with the result.
+func (h *handler) perform(w http.ResponseWriter, r *http.Request, op, key string) {
+	switch op {
+	case "list":
+		values, err := h.vault.Values()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "vault unavailable")
+			return
+		}
+		keys := Keys{Keys: []string{}}
+		for k := range values {
+			keys.Keys = append(keys.Keys, k)
+		}
+		sort.Strings(keys.Keys)
+		respond(w, http.StatusOK, keys)
+	case "get":
+		value, err := h.vault.Get(key)
+		if err == secret.ErrNoValue {
+			respondError(w, http.StatusNotFound, "no value for that key")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "vault unavailable")
+			return
+		}
+		respond(w, http.StatusOK, Value{Key: key, Value: value})
+	case "set":
+		var body Value
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		if err := h.vault.Set(key, body.Value); err != nil {
+			respondError(w, http.StatusInternalServerError, "vault unavailable")
+			return
+		}
+		respond(w, http.StatusOK, Value{Key: key, Value: body.Value})
+	default:
+		err := h.vault.Remove(key)
+		if err == secret.ErrNoValue {
+			respondError(w, http.StatusNotFound, "no value for that key")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "vault unavailable")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func respondError(w http.ResponseWriter, status int, msg string) {
+	respond(w, status, errorBody{Error: msg})
+}
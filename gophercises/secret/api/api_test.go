@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gophercises/secret"
+	"gophercises/secret/cipher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	cipher.DefaultKDF = cipher.KDFParams{Name: "scrypt", N: 1 << 10, R: 8, P: 1}
+}
+
+func newServer(t *testing.T) (*httptest.Server, *secret.Vault) {
+	dir, err := ioutil.TempDir("", "secret-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	v := secret.File("test_key", filepath.Join(dir, "secrets"))
+	s := httptest.NewServer(NewHandler(v))
+	t.Cleanup(s.Close)
+	return s, v
+}
+
+func do(t *testing.T, method, url, token, body string) (int, string) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestHandler(t *testing.T) {
+	s, v := newServer(t)
+	reader, _ := v.CreateToken("reader", secret.ScopeRead)
+	writer, _ := v.CreateToken("writer", secret.ScopeRead, secret.ScopeWrite)
+	url := s.URL + "/v1/secrets"
+
+	t.Run("it requires a valid token", func(t *testing.T) {
+		status, _ := do(t, "GET", url, "", "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+		status, _ = do(t, "GET", url, "st_wrong", "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("it sets, gets, lists and deletes with write scope", func(t *testing.T) {
+		status, _ := do(t, "PUT", url+"/db_pass", writer, `{"value":"hunter2"}`)
+		assert.Equal(t, http.StatusOK, status)
+
+		status, body := do(t, "GET", url+"/db_pass", reader, "")
+		assert.Equal(t, http.StatusOK, status)
+		var value Value
+		assert.Nil(t, json.Unmarshal([]byte(body), &value))
+		assert.Equal(t, Value{Key: "db_pass", Value: "hunter2"}, value)
+
+		status, body = do(t, "GET", url, reader, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"keys":["db_pass"]}`, body)
+
+		status, _ = do(t, "DELETE", url+"/db_pass", writer, "")
+		assert.Equal(t, http.StatusNoContent, status)
+		status, _ = do(t, "GET", url+"/db_pass", reader, "")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("it forbids writes with a read-only token", func(t *testing.T) {
+		status, _ := do(t, "PUT", url+"/k", reader, `{"value":"v"}`)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = do(t, "DELETE", url+"/k", reader, "")
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("it rejects bad requests", func(t *testing.T) {
+		status, _ := do(t, "PUT", url+"/k", writer, `not json`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do(t, "POST", url, writer, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		status, _ = do(t, "GET", s.URL+"/other", writer, "")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("it audits requests in the vault under the token name", func(t *testing.T) {
+		records, err := v.AuditLog()
+		assert.Nil(t, err)
+		clients := make(map[string]string)
+		for _, rec := range records {
+			if rec.Key == "db_pass" {
+				clients[rec.Op] = rec.User
+			}
+		}
+		assert.Equal(t, "writer", clients["set"])
+		assert.Equal(t, "reader", clients["get"])
+		assert.Equal(t, "writer", clients["remove"])
+
+		v.Set("local", "value")
+		records, _ = v.AuditLog()
+		assert.NotEqual(t, "writer", records[len(records)-1].User)
+	})
+
+	t.Run("it audits refused requests", func(t *testing.T) {
+		records, err := v.AuditLog()
+		assert.Nil(t, err)
+		denied := make(map[string]string)
+		for _, rec := range records {
+			if rec.Error != "" {
+				denied[rec.Op+" "+rec.Key+" by "+rec.User] = rec.Error
+			}
+		}
+		assert.Equal(t, "missing bearer token", denied["list  by anonymous"])
+		assert.Equal(t, "invalid token", denied["list  by token "+secret.TokenID("st_wrong")])
+		assert.Equal(t, "token lacks the write scope", denied["set k by reader"])
+		assert.Equal(t, "token lacks the write scope", denied["remove k by reader"])
+	})
+}
+
+func TestKeyDerivation(t *testing.T) {
+	derived := 0
+	cipher.RegisterKDF(cipher.KDFParams{Name: "counting", N: 1 << 10, R: 8, P: 1}, func(passphrase []byte, p cipher.KDFParams) ([]byte, error) {
+		derived++
+		p.Name = "scrypt"
+		return cipher.DeriveKey(string(passphrase), p)
+	})
+	defaults := cipher.DefaultKDF
+	defer func() { cipher.DefaultKDF = defaults }()
+	cipher.DefaultKDF, _ = cipher.DefaultParams("counting")
+
+	dir, _ := ioutil.TempDir("", "secret-api")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets")
+	v := secret.File("test_key", path)
+	s := httptest.NewServer(NewHandler(v))
+	defer s.Close()
+	reader, _ := v.CreateToken("reader", secret.ScopeRead)
+	v.Set("db_pass", "hunter2")
+	url := s.URL + "/v1/secrets"
+	do(t, "GET", url+"/db_pass", reader, "")
+
+	t.Run("it doesn't derive the key per request", func(t *testing.T) {
+		before := derived
+		for i := 0; i < 5; i++ {
+			status, _ := do(t, "GET", url, "st_wrong", "")
+			assert.Equal(t, http.StatusUnauthorized, status)
+		}
+		status, _ := do(t, "GET", url+"/db_pass", reader, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, before, derived)
+	})
+
+	t.Run("it reloads the vault once it changed", func(t *testing.T) {
+		other := secret.File("test_key", path)
+		assert.Nil(t, other.Set("db_pass", "changed"))
+		_, body := do(t, "GET", url+"/db_pass", reader, "")
+		assert.Contains(t, body, "changed")
+	})
+}
+
+func TestListen(t *testing.T) {
+	t.Run("it refuses non-loopback addresses", func(t *testing.T) {
+		_, err := Listen("0.0.0.0:0")
+		assert.NotNil(t, err)
+		_, err = Listen("example.com:80")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it listens on loopback and unix sockets", func(t *testing.T) {
+		l, err := Listen("127.0.0.1:0")
+		assert.Nil(t, err)
+		l.Close()
+
+		dir, _ := ioutil.TempDir("", "secret-api")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "api.sock")
+		l, err = Listen(path)
+		assert.Nil(t, err)
+		info, _ := os.Stat(path)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		_, err = Listen(path)
+		assert.NotNil(t, err)
+		l.Close()
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+)
+
+// Listen opens the listener for addr. An addr containing a "/" is a Unix
+// socket path, made accessible to the owner only; anything else is a
+// host:port that must be a loopback address.
+func Listen(addr string) (net.Listener, error) {
+	if strings.Contains(addr, "/") {
+		path := strings.TrimPrefix(addr, "unix:")
+		if info, err := os.Lstat(path); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, errors.New("api: " + path + " exists and is not a socket")
+			}
+			if conn, err := net.Dial("unix", path); err == nil {
+				conn.Close()
+				return nil, errors.New("api: another server is already listening on " + path)
+			}
+			os.Remove(path)
+		}
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			l.Close()
+			return nil, err
+		}
+		return l, nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(host)
+	if host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, errors.New("api: refusing to listen on non-loopback address " + addr)
+	}
+	return net.Listen("tcp", addr)
+}
@@ -0,0 +1,78 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// rename is os.Rename, replaced in tests to fail the last step of a write.
+var rename = os.Rename
+
+// writeFileAtomic writes data to a temporary file next to path, syncs it
+// and renames it over path, so a crash leaves either the old or the new
+// contents but never a mix. With backup set, the previous generation is
+// kept as path.bak.
+func writeFileAtomic(path string, data []byte, perm os.FileMode, backup bool) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := ioutil.TempFile(dir, "."+base+".tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if backup {
+		if err := backupFile(path, perm); err != nil {
+			return err
+		}
+	}
+	if err := rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// backupFile makes path.bak a copy of path, if path exists.
+func backupFile(path string, perm os.FileMode) error {
+	bak := path + ".bak"
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Link(path, bak)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(bak, data, perm, false)
+}
+
+// syncDir flushes a rename in dir to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,54 @@
+package secret
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "vault")
+
+	t.Run("it replaces a longer file completely", func(t *testing.T) {
+		assert.Nil(t, writeFileAtomic(path, []byte("a much longer payload"), 0600, false))
+		assert.Nil(t, writeFileAtomic(path, []byte("short"), 0600, false))
+		data, _ := ioutil.ReadFile(path)
+		assert.Equal(t, "short", string(data))
+	})
+
+	t.Run("it leaves no temporary files behind", func(t *testing.T) {
+		assert.Nil(t, writeFileAtomic(path, []byte("x"), 0600, false))
+		files, _ := ioutil.ReadDir(dir)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("it keeps the old file when the write fails", func(t *testing.T) {
+		assert.Nil(t, writeFileAtomic(path, []byte("old"), 0600, false))
+		rename = func(string, string) error { return errors.New("disk full") }
+		defer func() { rename = os.Rename }()
+		err := writeFileAtomic(path, []byte("new"), 0600, false)
+		assert.NotNil(t, err)
+		data, _ := ioutil.ReadFile(path)
+		assert.Equal(t, "old", string(data))
+		files, _ := ioutil.ReadDir(dir)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("it backs up the previous generation", func(t *testing.T) {
+		assert.Nil(t, writeFileAtomic(path, []byte("one"), 0600, true))
+		assert.Nil(t, writeFileAtomic(path, []byte("two"), 0600, true))
+		data, _ := ioutil.ReadFile(path + ".bak")
+		assert.Equal(t, "one", string(data))
+		data, _ = ioutil.ReadFile(path)
+		assert.Equal(t, "two", string(data))
+	})
+}
@@ -0,0 +1,490 @@
+package secret
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"time"
+
+	"gophercises/secret/cipher"
+)
+
+var (
+	// ErrAuditTampered is returned when entries of the audit log were
+	// modified, reordered, removed or added behind the vault's back.
+	ErrAuditTampered = errors.New("secret: audit log has been tampered with")
+	// ErrAuditKey is returned when the audit log was sealed with another
+	// encoding key than the vault.
+	ErrAuditKey = errors.New("secret: audit log is sealed with another encoding key")
+)
+
+// AuditRecord is one entry of the audit log of a vault.
+type AuditRecord struct {
+	Seq   int       `json:"seq"`
+	Time  time.Time `json:"time"`
+	Op    string    `json:"op"`
+	Key   string    `json:"key,omitempty"`
+	User  string    `json:"user"`
+	Host  string    `json:"host"`
+	Error string    `json:"error,omitempty"`
+	// Prev is the hash of the previous line of the log, which chains
+	// every entry to the ones before it.
+	Prev string `json:"prev"`
+}
+
+// auditHeader is the plaintext first line of an audit log. Check is a
+// known value sealed with the log key, telling a wrong key apart from a
+// modified entry.
+type auditHeader struct {
+	KDF   cipher.KDFParams `json:"kdf"`
+	Check []byte           `json:"check"`
+}
+
+// auditHead records where the log ends. It is kept in a separate sealed
+// file, so removing entries from the end is detected too, and as of the
+// last change in the vault document, so removing or rolling back both
+// files is detected as well. A head without a hash only checks the count.
+type auditHead struct {
+	Seq  int    `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+var auditCheck = []byte("secret audit log")
+
+// auditUser and auditHost identify who performed an operation.
+var auditUser = currentUser
+var auditHost = currentHost
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func currentHost() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+// SetAuditUser makes the audit log record user as who performed the
+// operations that follow, rather than the user running the process, until
+// it is set back to "". The API sets it to the name of the client token.
+func (v *Vault) SetAuditUser(user string) {
+	v.mutex.Lock()
+	v.auditAs = user
+	v.mutex.Unlock()
+}
+
+// RecordDenied records that op on key was refused with err, for an access
+// that was turned away before reaching the vault, such as an API request
+// without a valid token. The user is the one set with SetAuditUser.
+func (v *Vault) RecordDenied(op, key string, err error) error {
+	unlock, lerr := v.lock(false)
+	if lerr != nil {
+		return lerr
+	}
+	defer unlock()
+	if lerr := v.Load(); lerr != nil {
+		return lerr
+	}
+	if !v.local() {
+		return nil
+	}
+	return v.appendAudit(op, key, err)
+}
+
+func (v *Vault) auditPath() string {
+	return v.filepath + ".audit"
+}
+
+func (v *Vault) auditHeadPath() string {
+	return v.filepath + ".audit.head"
+}
+
+func chainHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// record appends op on key to the audit log and returns opErr. An error
+// writing the log is only returned when op itself succeeded. Vaults in
+// stores that aren't local have no audit log.
+func (v *Vault) record(op, key string, opErr error) error {
+	if !v.local() {
+		return opErr
+	}
+	if err := v.appendAudit(op, key, opErr); err != nil && opErr == nil {
+		return err
+	}
+	return opErr
+}
+
+func (v *Vault) appendAudit(op, key string, opErr error) error {
+	l, err := lockFile(v.filepath+".audit.lock", true, v.timeout())
+	if err != nil {
+		return err
+	}
+	defer l.unlock()
+	f, err := os.OpenFile(v.auditPath(), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	logKey, head, err := v.openAudit(f)
+	if err != nil {
+		return err
+	}
+
+	rec := AuditRecord{
+		Seq:  head.Seq + 1,
+		Time: now(),
+		Op:   op,
+		Key:  key,
+		User: v.auditAs,
+		Host: auditHost(),
+		Prev: head.Hash,
+	}
+	if rec.User == "" {
+		rec.User = auditUser()
+	}
+	if opErr != nil {
+		rec.Error = opErr.Error()
+	}
+	line, err := sealAuditRecord(logKey, rec)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return v.writeAuditHead(logKey, auditHead{Seq: rec.Seq, Hash: chainHash(line)})
+}
+
+// openAudit reads the key and head of the log opened as f, writing the
+// header first if the log is empty. It is called with the log locked.
+func (v *Vault) openAudit(f *os.File) ([]byte, auditHead, error) {
+	head := auditHead{}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, head, err
+	}
+	if info.Size() == 0 {
+		if _, err := os.Stat(v.auditHeadPath()); err == nil || v.auditAnchor != nil {
+			return nil, head, fmt.Errorf("%w: log is missing", ErrAuditTampered)
+		}
+		header, key, err := v.newAuditHeader()
+		if err != nil {
+			return nil, head, err
+		}
+		line, _ := json.Marshal(header)
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return nil, head, err
+		}
+		head.Hash = chainHash(line)
+		if err := f.Sync(); err != nil {
+			return nil, head, err
+		}
+		return key, head, v.writeAuditHead(key, head)
+	}
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return nil, head, ErrAuditTampered
+	}
+	key, err := v.openAuditHeader(bytes.TrimSuffix(line, []byte("\n")))
+	if err != nil {
+		return nil, head, err
+	}
+	if head, err = v.readAuditHead(key); err != nil {
+		return nil, head, err
+	}
+	if v.auditAnchor != nil && head.Seq < v.auditAnchor.Seq {
+		return nil, head, fmt.Errorf("%w: log ends before the last change of the vault", ErrAuditTampered)
+	}
+	return key, head, nil
+}
+
+// anchorAudit sets the head of the log that Save stores in the vault
+// document, creating the log if there is none yet. During a reseal the
+// head was set by reseal, as the log is about to be rewritten.
+func (v *Vault) anchorAudit() error {
+	if !v.local() || v.resealing {
+		return nil
+	}
+	l, err := lockFile(v.filepath+".audit.lock", true, v.timeout())
+	if err != nil {
+		return err
+	}
+	defer l.unlock()
+	f, err := os.OpenFile(v.auditPath(), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, head, err := v.openAudit(f)
+	if err != nil {
+		return err
+	}
+	v.auditAnchor = &head
+	return nil
+}
+
+func (v *Vault) newAuditHeader() (auditHeader, []byte, error) {
+	params := v.kdf
+	if params.Name == "" {
+		params = cipher.DefaultKDF
+	}
+	params.Salt = make([]byte, 16)
+	if _, err := rand.Read(params.Salt); err != nil {
+		return auditHeader{}, nil, err
+	}
+	key, err := v.deriveAuditKey(params)
+	if err != nil {
+		return auditHeader{}, nil, err
+	}
+	check, err := cipher.SealKey(key, auditCheck, nil)
+	if err != nil {
+		return auditHeader{}, nil, err
+	}
+	return auditHeader{KDF: params, Check: check}, key, nil
+}
+
+// auditSecret is what the audit log key is derived from: the encoding
+// key, or the data key of a vault shared with recipients.
+func (v *Vault) auditSecret() string {
+	if v.shared() {
+		return hex.EncodeToString(v.dataKey)
+	}
+	return v.encodingKey
+}
+
+// deriveAuditKey derives the log key, remembering the last one so the
+// key derivation runs once per log rather than once per entry.
+func (v *Vault) deriveAuditKey(params cipher.KDFParams) ([]byte, error) {
+	secret := v.auditSecret()
+	if v.auditKey != nil && v.auditKeyOf == secret && bytes.Equal(v.auditSalt, params.Salt) {
+		return v.auditKey, nil
+	}
+	key, err := cipher.DeriveKey(secret, params)
+	if err != nil {
+		return nil, err
+	}
+	v.auditKey, v.auditKeyOf, v.auditSalt = key, secret, params.Salt
+	return key, nil
+}
+
+// openAuditHeader derives the log key from the header line and checks it
+// against the encoding key.
+func (v *Vault) openAuditHeader(line []byte) ([]byte, error) {
+	var header auditHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, ErrAuditTampered
+	}
+	key, err := v.deriveAuditKey(header.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cipher.OpenKey(key, header.Check, nil); err != nil {
+		return nil, ErrAuditKey
+	}
+	return key, nil
+}
+
+func (v *Vault) readAuditHead(key []byte) (auditHead, error) {
+	var head auditHead
+	data, err := ioutil.ReadFile(v.auditHeadPath())
+	if err != nil {
+		return head, fmt.Errorf("%w: head is missing", ErrAuditTampered)
+	}
+	plain, err := cipher.OpenKey(key, data, []byte("head"))
+	if err != nil {
+		return head, fmt.Errorf("%w: head can't be decrypted", ErrAuditTampered)
+	}
+	err = json.Unmarshal(plain, &head)
+	return head, err
+}
+
+func (v *Vault) writeAuditHead(key []byte, head auditHead) error {
+	plain, _ := json.Marshal(head)
+	data, err := cipher.SealKey(key, plain, []byte("head"))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(v.auditHeadPath(), data, 0600, false)
+}
+
+func sealAuditRecord(key []byte, rec AuditRecord) ([]byte, error) {
+	plain, _ := json.Marshal(rec)
+	data, err := cipher.SealKey(key, plain, nil)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// AuditLog decrypts the audit log and verifies that its entries form an
+// unbroken chain up to the recorded head, past the head stored with the
+// last change of the vault. The entries read before any problem was found
+// are returned along with ErrAuditTampered.
+func (v *Vault) AuditLog() ([]AuditRecord, error) {
+	if !v.local() {
+		return nil, errors.New("secret: only vaults in local stores have an audit log")
+	}
+	unlock, err := v.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := v.Load(); err != nil {
+		return nil, err
+	}
+	return v.auditLog()
+}
+
+func (v *Vault) auditLog() ([]AuditRecord, error) {
+	l, err := lockFile(v.filepath+".audit.lock", false, v.timeout())
+	if err != nil {
+		return nil, err
+	}
+	defer l.unlock()
+	data, err := ioutil.ReadFile(v.auditPath())
+	if os.IsNotExist(err) {
+		if _, err := os.Stat(v.auditHeadPath()); err == nil || v.auditAnchor != nil {
+			return nil, fmt.Errorf("%w: log is missing", ErrAuditTampered)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	key, err := v.openAuditHeader(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	anchor := auditHead{}
+	if v.auditAnchor != nil {
+		anchor = *v.auditAnchor
+	}
+	var records []AuditRecord
+	prev := chainHash(lines[0])
+	if anchor.Seq == 0 && anchor.Hash != "" && anchor.Hash != prev {
+		return nil, fmt.Errorf("%w: log was replaced", ErrAuditTampered)
+	}
+	for i, line := range lines[1:] {
+		seq := i + 1
+		sealed, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			return records, fmt.Errorf("%w: entry %d is malformed", ErrAuditTampered, seq)
+		}
+		plain, err := cipher.OpenKey(key, sealed, nil)
+		if err != nil {
+			return records, fmt.Errorf("%w: entry %d was modified", ErrAuditTampered, seq)
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(plain, &rec); err != nil {
+			return records, fmt.Errorf("%w: entry %d is malformed", ErrAuditTampered, seq)
+		}
+		if rec.Seq != seq || rec.Prev != prev {
+			return records, fmt.Errorf("%w: entry %d is out of sequence", ErrAuditTampered, seq)
+		}
+		records = append(records, rec)
+		prev = chainHash(line)
+		if seq == anchor.Seq && anchor.Hash != "" && anchor.Hash != prev {
+			return records, fmt.Errorf("%w: log was replaced", ErrAuditTampered)
+		}
+	}
+	// the change that stored the anchor is recorded after it
+	if v.auditAnchor != nil && len(records) <= anchor.Seq {
+		return records, fmt.Errorf("%w: log ends before the last change of the vault", ErrAuditTampered)
+	}
+	head, err := v.readAuditHead(key)
+	if err != nil {
+		return records, err
+	}
+	if head.Seq != len(records) || head.Hash != prev {
+		return records, fmt.Errorf("%w: log ends at entry %d but the head is at %d", ErrAuditTampered, len(records), head.Seq)
+	}
+	return records, nil
+}
+
+// reseal runs change, which makes the vault sealed under another secret,
+// and carries the audit log over to the new secret, recording op on key.
+// It is called with the vault locked and loaded.
+func (v *Vault) reseal(op, key string, change func() error) error {
+	var records []AuditRecord
+	if v.local() {
+		var err error
+		if records, err = v.auditLog(); err != nil {
+			return err
+		}
+	}
+	// the hashes of the log change with its key, so only the count of
+	// the carried over entries is kept in the vault
+	anchor := v.auditAnchor
+	v.auditAnchor, v.resealing = nil, true
+	if len(records) > 0 {
+		v.auditAnchor = &auditHead{Seq: len(records)}
+	}
+	err := change()
+	v.resealing = false
+	if err != nil {
+		v.auditAnchor = anchor
+		return err
+	}
+	if !v.local() {
+		return nil
+	}
+	if err := v.rekeyAudit(records); err != nil {
+		return err
+	}
+	return v.record(op, key, nil)
+}
+
+// rekeyAudit reseals records under the current secret, re-chaining them
+// under a fresh log key.
+func (v *Vault) rekeyAudit(records []AuditRecord) error {
+	if len(records) == 0 {
+		os.Remove(v.auditPath())
+		os.Remove(v.auditHeadPath())
+		return nil
+	}
+	header, key, err := v.newAuditHeader()
+	if err != nil {
+		return err
+	}
+	line, _ := json.Marshal(header)
+	var buf bytes.Buffer
+	buf.Write(append(line, '\n'))
+	prev := chainHash(line)
+	for _, rec := range records {
+		rec.Prev = prev
+		line, err := sealAuditRecord(key, rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+		prev = chainHash(line)
+	}
+	l, err := lockFile(v.filepath+".audit.lock", true, v.timeout())
+	if err != nil {
+		return err
+	}
+	defer l.unlock()
+	if err := writeFileAtomic(v.auditPath(), buf.Bytes(), 0600, false); err != nil {
+		return err
+	}
+	return v.writeAuditHead(key, auditHead{Seq: len(records), Hash: prev})
+}
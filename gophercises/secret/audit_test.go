@@ -0,0 +1,177 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	Cipher "gophercises/secret/cipher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func removeVault(v *Vault) {
+	for _, suffix := range []string{"", ".audit", ".audit.head"} {
+		os.Remove(v.filepath + suffix)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	defer func() { auditUser, auditHost = currentUser, currentHost }()
+	auditUser = func() string { return "alice" }
+	auditHost = func() string { return "laptop" }
+
+	t.Run("it records get, set and remove", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		assert.Nil(t, v.Set("test_key", "value"))
+		v.Get("test_key")
+		v.Get("missing")
+		assert.Nil(t, v.Remove("test_key"))
+
+		records, err := v.AuditLog()
+		assert.Nil(t, err)
+		assert.Len(t, records, 4)
+		assert.Equal(t, "set", records[0].Op)
+		assert.Equal(t, "test_key", records[0].Key)
+		assert.Equal(t, "alice", records[0].User)
+		assert.Equal(t, "laptop", records[0].Host)
+		assert.Equal(t, ErrNoValue.Error(), records[2].Error)
+		assert.Equal(t, 4, records[3].Seq)
+
+		data, _ := ioutil.ReadFile(v.auditPath())
+		assert.NotContains(t, string(data), "test_key")
+	})
+
+	t.Run("it records reads of every value, imports, tokens and retention", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.Set("a", "1")
+		_, err := v.Import(map[string]string{"b": "2", "c": "3"}, Overwrite, true)
+		assert.Nil(t, err)
+		_, err = v.Values()
+		assert.Nil(t, err)
+		v.CreateToken("ci", ScopeRead)
+		v.RevokeToken("ci")
+		v.RevokeToken("ci")
+		v.SetRetention(3)
+
+		records, err := v.AuditLog()
+		assert.Nil(t, err)
+		var ops []string
+		for _, rec := range records {
+			ops = append(ops, rec.Op+" "+rec.Key)
+		}
+		assert.Equal(t, []string{
+			"set a", "import b", "import c", "remove a", "read-all ",
+			"token create ci", "token revoke ci", "token revoke ci", "retention ",
+		}, ops)
+		assert.Equal(t, ErrNoToken.Error(), records[7].Error)
+	})
+
+	t.Run("it detects a modified entry", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.Set("a", "1")
+		v.Set("b", "2")
+		data, _ := ioutil.ReadFile(v.auditPath())
+		lines := bytes.Split(data, []byte("\n"))
+		lines[1][10] ^= 0x01
+		ioutil.WriteFile(v.auditPath(), bytes.Join(lines, []byte("\n")), 0600)
+
+		_, err := v.AuditLog()
+		assert.True(t, errors.Is(err, ErrAuditTampered))
+	})
+
+	t.Run("it detects removed and reordered entries", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.Set("a", "1")
+		v.Set("b", "2")
+		v.Set("c", "3")
+		data, _ := ioutil.ReadFile(v.auditPath())
+		lines := bytes.Split(data, []byte("\n"))
+
+		removed := [][]byte{lines[0], lines[1], lines[3], lines[4]}
+		ioutil.WriteFile(v.auditPath(), bytes.Join(removed, []byte("\n")), 0600)
+		records, err := v.AuditLog()
+		assert.True(t, errors.Is(err, ErrAuditTampered))
+		assert.Len(t, records, 1)
+
+		truncated := [][]byte{lines[0], lines[1], lines[2], nil}
+		ioutil.WriteFile(v.auditPath(), bytes.Join(truncated, []byte("\n")), 0600)
+		records, err = v.AuditLog()
+		assert.True(t, errors.Is(err, ErrAuditTampered))
+		assert.Len(t, records, 2)
+	})
+
+	t.Run("it detects a deleted log", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.Set("a", "1")
+		os.Remove(v.auditPath())
+		_, err := v.AuditLog()
+		assert.True(t, errors.Is(err, ErrAuditTampered))
+		assert.NotNil(t, v.Set("b", "2"))
+	})
+
+	t.Run("it detects a deleted log and head", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.Set("a", "1")
+		os.Remove(v.auditPath())
+		os.Remove(v.auditHeadPath())
+		_, err := File(v.encodingKey, v.filepath).AuditLog()
+		assert.True(t, errors.Is(err, ErrAuditTampered))
+		assert.NotNil(t, v.Set("b", "2"))
+	})
+
+	t.Run("it detects a log and head rolled back together", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.Set("a", "1")
+		log, _ := ioutil.ReadFile(v.auditPath())
+		head, _ := ioutil.ReadFile(v.auditHeadPath())
+		v.Set("b", "2")
+		ioutil.WriteFile(v.auditPath(), log, 0600)
+		ioutil.WriteFile(v.auditHeadPath(), head, 0600)
+
+		records, err := File(v.encodingKey, v.filepath).AuditLog()
+		assert.True(t, errors.Is(err, ErrAuditTampered))
+		assert.Len(t, records, 1)
+	})
+
+	t.Run("it starts the log when rekeying a vault without one", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		data, _ := Cipher.SealWith(v.encodingKey, Cipher.DefaultKDF, []byte(`{"secrets":{}}`))
+		ioutil.WriteFile(v.filepath, data, 0600)
+		assert.Nil(t, v.Rekey("newkey", Cipher.DefaultKDF))
+
+		records, err := v.AuditLog()
+		assert.Nil(t, err)
+		assert.Len(t, records, 1)
+	})
+
+	t.Run("it keeps the log readable after a rekey", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.Set("a", "1")
+		assert.Nil(t, v.Rekey("newkey", v.kdf))
+
+		records, err := File("newkey", v.filepath).AuditLog()
+		assert.Nil(t, err)
+		assert.Len(t, records, 2)
+		assert.Equal(t, "rekey", records[1].Op)
+
+		_, err = File("testencodingKey", v.filepath).AuditLog()
+		assert.Equal(t, Cipher.ErrAuthentication, err)
+
+		v.Set("b", "2")
+		records, err = v.AuditLog()
+		assert.Nil(t, err)
+		assert.Len(t, records, 3)
+	})
+}
@@ -53,8 +53,9 @@ func DecryptStream(key string, iv []byte) (cipher.Stream, error) {
 	return cipher.NewCFBDecrypter(block, iv), nil
 }
 
-// NewCipherBlock creates and returns a new cipher.Block.
-// The key argument should be the AES key, either 16, 24, or 32 bytes.
+// NewCipherBlock creates and returns a new cipher.Block keyed with the MD5
+// of key. It is only used to read the legacy CFB format; sealed data
+// derives its key with a KDF instead.
 func NewCipherBlock(key string) (cipher.Block, error) {
 	h := md5.New()
 	fmt.Fprint(h, key)
@@ -0,0 +1,138 @@
+package cipher
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keySize  = 32
+	saltSize = 16
+)
+
+// The highest costs DeriveKey accepts. Parameters are read from the
+// unauthenticated header of a file, so without limits a crafted file
+// could make opening it take all memory or never finish.
+const (
+	maxMemory     = 1 << 30 // bytes
+	maxTime       = 64
+	maxThreads    = 64
+	maxScryptP    = 64
+	maxIterations = 100000000
+)
+
+// ErrKDFCost is returned by DeriveKey for parameters above the limits.
+var ErrKDFCost = errors.New("cipher: key derivation cost is too high")
+
+// KDFParams describes how the encryption key is derived from a passphrase.
+// Only the fields used by the named KDF are set.
+type KDFParams struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+
+	// argon2id
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+
+	// pbkdf2
+	Iterations int `json:"iterations,omitempty"`
+}
+
+// KDF derives a keySize-byte key from a passphrase using params.
+type KDF func(passphrase []byte, params KDFParams) ([]byte, error)
+
+var kdfs = map[string]KDF{}
+var kdfDefaults = map[string]KDFParams{}
+
+// DefaultKDF holds the key derivation parameters used by Seal.
+var DefaultKDF KDFParams
+
+func init() {
+	RegisterKDF(KDFParams{Name: "argon2id", Time: 3, Memory: 64 * 1024, Threads: 4}, argon2idKey)
+	RegisterKDF(KDFParams{Name: "scrypt", N: 1 << 15, R: 8, P: 1}, scryptKey)
+	RegisterKDF(KDFParams{Name: "pbkdf2", Iterations: 600000}, pbkdf2Key)
+	DefaultKDF, _ = DefaultParams("argon2id")
+}
+
+// RegisterKDF makes a key derivation function available under
+// defaults.Name, with defaults as its recommended parameters.
+func RegisterKDF(defaults KDFParams, kdf KDF) {
+	kdfs[defaults.Name] = kdf
+	kdfDefaults[defaults.Name] = defaults
+}
+
+// KDFs returns the names of the registered key derivation functions.
+func KDFs() []string {
+	var names []string
+	for name := range kdfs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultParams returns the recommended parameters for the named KDF.
+func DefaultParams(name string) (KDFParams, error) {
+	params, ok := kdfDefaults[name]
+	if !ok {
+		return params, fmt.Errorf("cipher: unknown key derivation function %q", name)
+	}
+	return params, nil
+}
+
+// DeriveKey derives the encryption key for passphrase using params. It
+// returns ErrKDFCost for parameters needing more than 1 GiB of memory
+// or an unreasonable amount of time.
+func DeriveKey(passphrase string, params KDFParams) ([]byte, error) {
+	kdf, ok := kdfs[params.Name]
+	if !ok {
+		return nil, fmt.Errorf("cipher: unknown key derivation function %q", params.Name)
+	}
+	if err := checkCost(params); err != nil {
+		return nil, err
+	}
+	return kdf([]byte(passphrase), params)
+}
+
+// checkCost returns ErrKDFCost for parameters above the limits.
+func checkCost(p KDFParams) error {
+	switch {
+	case uint64(p.Memory)*1024 > maxMemory, p.Time > maxTime, p.Threads > maxThreads:
+	// scrypt needs 128*N*R bytes
+	case p.R > 0 && p.N > maxMemory/128/p.R, p.R > maxMemory/128, p.P > maxScryptP:
+	case p.Iterations > maxIterations:
+	default:
+		return nil
+	}
+	return ErrKDFCost
+}
+
+func argon2idKey(passphrase []byte, p KDFParams) ([]byte, error) {
+	if p.Time == 0 || p.Memory == 0 || p.Threads == 0 {
+		return nil, errors.New("cipher: argon2id time, memory and threads must be positive")
+	}
+	return argon2.IDKey(passphrase, p.Salt, p.Time, p.Memory, p.Threads, keySize), nil
+}
+
+func scryptKey(passphrase []byte, p KDFParams) ([]byte, error) {
+	return scrypt.Key(passphrase, p.Salt, p.N, p.R, p.P, keySize)
+}
+
+func pbkdf2Key(passphrase []byte, p KDFParams) ([]byte, error) {
+	if p.Iterations <= 0 {
+		return nil, errors.New("cipher: pbkdf2 iterations must be positive")
+	}
+	return pbkdf2.Key(passphrase, p.Salt, p.Iterations, keySize, sha256.New), nil
+}
@@ -0,0 +1,105 @@
+package cipher
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var cheapParams = []KDFParams{
+	{Name: "argon2id", Time: 1, Memory: 1024, Threads: 1},
+	{Name: "scrypt", N: 1 << 10, R: 8, P: 1},
+	{Name: "pbkdf2", Iterations: 1000},
+}
+
+func TestDeriveKey(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+
+	for _, params := range cheapParams {
+		params.Salt = salt
+		t.Run("it derives a stable key with "+params.Name, func(t *testing.T) {
+			a, err := DeriveKey("test_key", params)
+			assert.Nil(t, err)
+			assert.Len(t, a, keySize)
+			b, _ := DeriveKey("test_key", params)
+			assert.Equal(t, a, b)
+			c, _ := DeriveKey("other_key", params)
+			assert.NotEqual(t, a, c)
+		})
+	}
+
+	t.Run("it returns error for an unknown kdf", func(t *testing.T) {
+		_, err := DeriveKey("test_key", KDFParams{Name: "md5"})
+		assert.Equal(t, `cipher: unknown key derivation function "md5"`, err.Error())
+	})
+
+	t.Run("it returns error for zero argon2id cost", func(t *testing.T) {
+		_, err := DeriveKey("test_key", KDFParams{Name: "argon2id"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it returns error for zero pbkdf2 iterations", func(t *testing.T) {
+		_, err := DeriveKey("test_key", KDFParams{Name: "pbkdf2"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it refuses costs above the limits", func(t *testing.T) {
+		for _, params := range []KDFParams{
+			{Name: "argon2id", Time: 1, Memory: 4294967295, Threads: 1},
+			{Name: "argon2id", Time: 1 << 20, Memory: 1024, Threads: 1},
+			{Name: "scrypt", N: 1 << 40, R: 8, P: 1},
+			{Name: "scrypt", N: 1 << 10, R: 1 << 20, P: 1},
+			{Name: "pbkdf2", Iterations: 1 << 40},
+		} {
+			_, err := DeriveKey("test_key", params)
+			assert.Equal(t, ErrKDFCost, err, params.Name)
+		}
+	})
+
+	t.Run("it opens data within the limits of the default parameters", func(t *testing.T) {
+		for _, name := range KDFs() {
+			params, _ := DefaultParams(name)
+			assert.Nil(t, checkCost(params), name)
+		}
+	})
+}
+
+func TestOpenRefusesCostlyHeader(t *testing.T) {
+	IoRead = io.ReadFull
+	data, _ := SealWith("test_key", cheapParams[0], []byte("x"))
+	h, _ := ReadHeader(data)
+	h.KDF.Memory = 4294967295
+	preamble, _ := marshalHeader(h)
+	_, err := Open("test_key", preamble)
+	assert.Equal(t, ErrKDFCost, err)
+}
+
+func TestDefaultParams(t *testing.T) {
+	assert.Equal(t, []string{"argon2id", "pbkdf2", "scrypt"}, KDFs())
+
+	params, err := DefaultParams("scrypt")
+	assert.Nil(t, err)
+	assert.Equal(t, 1<<15, params.N)
+
+	_, err = DefaultParams("md5")
+	assert.NotNil(t, err)
+}
+
+func TestSealWith(t *testing.T) {
+	IoRead = io.ReadFull
+
+	for _, params := range cheapParams {
+		t.Run("it stores "+params.Name+" params and salt in the header", func(t *testing.T) {
+			data, err := SealWith("test_key", params, []byte("x"))
+			assert.Nil(t, err)
+			h, err := ReadHeader(data)
+			assert.Nil(t, err)
+			assert.Equal(t, params.Name, h.KDF.Name)
+			assert.Len(t, h.KDF.Salt, saltSize)
+			plain, err := Open("test_key", data)
+			assert.Nil(t, err)
+			assert.Equal(t, "x", string(plain))
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// RecipientPrefix starts the text form of a recipient's public key.
+	RecipientPrefix = "secretpub1"
+	// IdentityPrefix starts the text form of an identity.
+	IdentityPrefix = "SECRET-IDENTITY-1"
+
+	wrapInfo = "gophercises/secret x25519"
+)
+
+var (
+	// ErrNoIdentity is returned by OpenFor when data isn't shared with
+	// the identity.
+	ErrNoIdentity = errors.New("cipher: data is not shared with this identity")
+	// ErrInvalidRecipient is returned for a malformed public key.
+	ErrInvalidRecipient = errors.New("cipher: invalid recipient public key")
+	// ErrInvalidIdentity is returned for a malformed private key.
+	ErrInvalidIdentity = errors.New("cipher: invalid identity")
+)
+
+// Identity is an X25519 key pair. Data sealed for its Recipient with
+// SealFor can be opened with it.
+type Identity struct {
+	key *ecdh.PrivateKey
+}
+
+// GenerateIdentity returns a new random identity.
+func GenerateIdentity() (*Identity, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{key: key}, nil
+}
+
+// ParseIdentity parses the text form returned by Identity.String.
+func ParseIdentity(s string) (*Identity, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, IdentityPrefix) {
+		return nil, ErrInvalidIdentity
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, IdentityPrefix))
+	if err != nil {
+		return nil, ErrInvalidIdentity
+	}
+	key, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return nil, ErrInvalidIdentity
+	}
+	return &Identity{key: key}, nil
+}
+
+// String returns the text form of the private key. Keep it secret.
+func (id *Identity) String() string {
+	return IdentityPrefix + base64.RawURLEncoding.EncodeToString(id.key.Bytes())
+}
+
+// Recipient returns the text form of the public key of id.
+func (id *Identity) Recipient() string {
+	return RecipientPrefix + base64.RawURLEncoding.EncodeToString(id.key.PublicKey().Bytes())
+}
+
+// ParseRecipient checks and normalises the text form of a public key.
+func ParseRecipient(s string) (string, error) {
+	_, err := parseRecipient(s)
+	return strings.TrimSpace(s), err
+}
+
+func parseRecipient(s string) (*ecdh.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, RecipientPrefix) {
+		return nil, ErrInvalidRecipient
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, RecipientPrefix))
+	if err != nil {
+		return nil, ErrInvalidRecipient
+	}
+	key, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, ErrInvalidRecipient
+	}
+	return key, nil
+}
+
+// Stanza holds the data key wrapped for one recipient: it is encrypted
+// under a key agreed between an ephemeral key pair and the recipient.
+type Stanza struct {
+	Recipient string `json:"recipient"`
+	Ephemeral []byte `json:"ephemeral"`
+	Key       []byte `json:"key"`
+}
+
+// SharedHeader is the plaintext preamble of data sealed with SealFor.
+type SharedHeader struct {
+	Stanzas []Stanza `json:"stanzas"`
+	Nonce   []byte   `json:"nonce"`
+}
+
+// IsShared reports whether data was sealed with SealFor.
+func IsShared(data []byte) bool {
+	return IsSealed(data) && len(data) > len(Magic) && data[len(Magic)] == SharedFormatVersion
+}
+
+// NewDataKey returns a random key for SealFor.
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := IoRead(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SealFor encrypts plaintext with AES-256-GCM under dataKey and wraps
+// dataKey for every recipient, so any of their identities can open it.
+func SealFor(recipients []string, dataKey, plaintext []byte) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("cipher: no recipients")
+	}
+	h := SharedHeader{}
+	for _, r := range recipients {
+		s, err := wrap(r, dataKey)
+		if err != nil {
+			return nil, err
+		}
+		h.Stanzas = append(h.Stanzas, s)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	h.Nonce = make([]byte, aead.NonceSize())
+	if _, err := IoRead(rand.Reader, h.Nonce); err != nil {
+		return nil, err
+	}
+	preamble, err := marshalPreamble(SharedFormatVersion, h)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(preamble, h.Nonce, plaintext, preamble), nil
+}
+
+// OpenFor decrypts data sealed with SealFor using id and returns the
+// plaintext along with the data key.
+func OpenFor(id *Identity, data []byte) ([]byte, []byte, error) {
+	var h SharedHeader
+	preamble, err := parsePreamble(data, SharedFormatVersion, &h)
+	if err != nil {
+		return nil, nil, err
+	}
+	me := id.Recipient()
+	for _, s := range h.Stanzas {
+		if s.Recipient != me {
+			continue
+		}
+		dataKey, err := unwrap(id, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		aead, err := newGCM(dataKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(h.Nonce) != aead.NonceSize() {
+			return nil, nil, ErrMalformedHeader
+		}
+		plaintext, err := aead.Open(nil, h.Nonce, data[len(preamble):], preamble)
+		if err != nil {
+			return nil, nil, ErrAuthentication
+		}
+		return plaintext, dataKey, nil
+	}
+	return nil, nil, ErrNoIdentity
+}
+
+// Recipients returns the public keys data sealed with SealFor is shared
+// with. They are not authenticated until the data is opened.
+func Recipients(data []byte) ([]string, error) {
+	var h SharedHeader
+	if _, err := parsePreamble(data, SharedFormatVersion, &h); err != nil {
+		return nil, err
+	}
+	var recipients []string
+	for _, s := range h.Stanzas {
+		recipients = append(recipients, s.Recipient)
+	}
+	return recipients, nil
+}
+
+func wrap(recipient string, dataKey []byte) (Stanza, error) {
+	pub, err := parseRecipient(recipient)
+	if err != nil {
+		return Stanza{}, err
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return Stanza{}, err
+	}
+	shared, err := ephemeral.ECDH(pub)
+	if err != nil {
+		return Stanza{}, err
+	}
+	epk := ephemeral.PublicKey().Bytes()
+	wrapped, err := SealKey(wrapKey(shared, epk, pub.Bytes()), dataKey, nil)
+	if err != nil {
+		return Stanza{}, err
+	}
+	return Stanza{Recipient: recipient, Ephemeral: epk, Key: wrapped}, nil
+}
+
+func unwrap(id *Identity, s Stanza) ([]byte, error) {
+	epk, err := ecdh.X25519().NewPublicKey(s.Ephemeral)
+	if err != nil {
+		return nil, ErrMalformedHeader
+	}
+	shared, err := id.key.ECDH(epk)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return OpenKey(wrapKey(shared, s.Ephemeral, id.key.PublicKey().Bytes()), s.Key, nil)
+}
+
+// wrapKey derives the key wrapping a data key from an X25519 shared
+// secret, bound to both public keys.
+func wrapKey(shared, ephemeral, recipient []byte) []byte {
+	salt := bytes.Join([][]byte{ephemeral, recipient}, nil)
+	key := make([]byte, keySize)
+	io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(wrapInfo)), key)
+	return key
+}
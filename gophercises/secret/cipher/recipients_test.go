@@ -0,0 +1,66 @@
+package cipher
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealFor(t *testing.T) {
+	IoRead = io.ReadFull
+	alice, _ := GenerateIdentity()
+	bob, _ := GenerateIdentity()
+	eve, _ := GenerateIdentity()
+	dataKey, err := NewDataKey()
+	assert.Nil(t, err)
+
+	data, err := SealFor([]string{alice.Recipient(), bob.Recipient()}, dataKey, []byte("shared"))
+	assert.Nil(t, err)
+	assert.True(t, IsShared(data))
+	assert.True(t, IsSealed(data))
+
+	t.Run("every recipient opens it with the same data key", func(t *testing.T) {
+		for _, id := range []*Identity{alice, bob} {
+			plain, key, err := OpenFor(id, data)
+			assert.Nil(t, err)
+			assert.Equal(t, "shared", string(plain))
+			assert.Equal(t, dataKey, key)
+		}
+	})
+
+	t.Run("others can't open it", func(t *testing.T) {
+		_, _, err := OpenFor(eve, data)
+		assert.Equal(t, ErrNoIdentity, err)
+		_, err = Open("passphrase", data)
+		assert.Equal(t, ErrUnsupportedVersion, err)
+	})
+
+	t.Run("it lists the recipients", func(t *testing.T) {
+		recipients, err := Recipients(data)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{alice.Recipient(), bob.Recipient()}, recipients)
+	})
+
+	t.Run("it detects a modified stanza or ciphertext", func(t *testing.T) {
+		tampered := append([]byte(nil), data...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, _, err := OpenFor(alice, tampered)
+		assert.Equal(t, ErrAuthentication, err)
+	})
+
+	t.Run("it rejects bad keys", func(t *testing.T) {
+		_, err := SealFor(nil, dataKey, []byte("x"))
+		assert.NotNil(t, err)
+		_, err = SealFor([]string{"secretpub1nope"}, dataKey, []byte("x"))
+		assert.Equal(t, ErrInvalidRecipient, err)
+		_, err = ParseIdentity("not an identity")
+		assert.Equal(t, ErrInvalidIdentity, err)
+	})
+
+	t.Run("it round trips identities", func(t *testing.T) {
+		parsed, err := ParseIdentity(alice.String() + "\n")
+		assert.Nil(t, err)
+		assert.Equal(t, alice.Recipient(), parsed.Recipient())
+	})
+}
@@ -0,0 +1,199 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+// FormatVersion is the version of the sealed format written by Seal.
+const FormatVersion = 1
+
+// SharedFormatVersion is the version of the format written by SealFor.
+const SharedFormatVersion = 2
+
+// Magic identifies data written in the sealed format.
+var Magic = []byte("GSVF")
+
+var (
+	// ErrNotSealed is returned by Open when data does not start with Magic.
+	ErrNotSealed = errors.New("cipher: data is not in the sealed format")
+	// ErrUnsupportedVersion is returned by Open for a format it can't read.
+	ErrUnsupportedVersion = errors.New("cipher: unsupported format version")
+	// ErrMalformedHeader is returned by Open when the header can't be parsed.
+	ErrMalformedHeader = errors.New("cipher: malformed header")
+	// ErrAuthentication is returned by Open when the key is wrong or the
+	// data has been modified.
+	ErrAuthentication = errors.New("cipher: authentication failed, wrong key or corrupted data")
+)
+
+// Header is the plaintext preamble of sealed data. It is authenticated
+// together with the ciphertext, so it cannot be altered undetected.
+type Header struct {
+	KDF   KDFParams `json:"kdf"`
+	Nonce []byte    `json:"nonce"`
+}
+
+// IsSealed reports whether data starts with the sealed format magic.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, Magic)
+}
+
+// Seal encrypts plaintext with AES-256-GCM under a key derived from
+// passphrase with DefaultKDF and returns it prefixed with a versioned
+// header.
+//
+// The layout is: magic (4 bytes), version (1 byte), header length
+// (uint16, big endian), JSON header, ciphertext.
+func Seal(passphrase string, plaintext []byte) ([]byte, error) {
+	return SealWith(passphrase, DefaultKDF, plaintext)
+}
+
+// SealWith is like Seal but derives the key with the given parameters.
+// Any salt in params is ignored; a fresh one is generated.
+func SealWith(passphrase string, params KDFParams, plaintext []byte) ([]byte, error) {
+	params.Salt = make([]byte, saltSize)
+	if _, err := IoRead(rand.Reader, params.Salt); err != nil {
+		return nil, err
+	}
+	key, err := DeriveKey(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	h := Header{KDF: params, Nonce: make([]byte, aead.NonceSize())}
+	if _, err := IoRead(rand.Reader, h.Nonce); err != nil {
+		return nil, err
+	}
+	preamble, err := marshalHeader(h)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(preamble, h.Nonce, plaintext, preamble), nil
+}
+
+// Open verifies and decrypts data produced by Seal. It returns
+// ErrAuthentication if passphrase is wrong or data was tampered with.
+func Open(passphrase string, data []byte) ([]byte, error) {
+	h, preamble, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := DeriveKey(passphrase, h.KDF)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Nonce) != aead.NonceSize() {
+		return nil, ErrMalformedHeader
+	}
+	plaintext, err := aead.Open(nil, h.Nonce, data[len(preamble):], preamble)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+// ReadHeader returns the header of sealed data without decrypting it.
+// The header is not authenticated until the data is opened.
+func ReadHeader(data []byte) (Header, error) {
+	h, _, err := parseHeader(data)
+	return h, err
+}
+
+func marshalHeader(h Header) ([]byte, error) {
+	return marshalPreamble(FormatVersion, h)
+}
+
+func marshalPreamble(version byte, h interface{}) ([]byte, error) {
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	preamble := make([]byte, 0, len(Magic)+3+len(hb))
+	preamble = append(preamble, Magic...)
+	preamble = append(preamble, version)
+	preamble = binary.BigEndian.AppendUint16(preamble, uint16(len(hb)))
+	return append(preamble, hb...), nil
+}
+
+// parseHeader returns the decoded header and the raw preamble bytes
+// that precede the ciphertext.
+func parseHeader(data []byte) (Header, []byte, error) {
+	var h Header
+	preamble, err := parsePreamble(data, FormatVersion, &h)
+	return h, preamble, err
+}
+
+// parsePreamble decodes the header of data written in the given version
+// of the format into h and returns the raw preamble bytes.
+func parsePreamble(data []byte, version byte, h interface{}) ([]byte, error) {
+	if !IsSealed(data) {
+		return nil, ErrNotSealed
+	}
+	rest := data[len(Magic):]
+	if len(rest) < 3 {
+		return nil, ErrMalformedHeader
+	}
+	if rest[0] != version {
+		return nil, ErrUnsupportedVersion
+	}
+	n := int(binary.BigEndian.Uint16(rest[1:3]))
+	if len(rest) < 3+n {
+		return nil, ErrMalformedHeader
+	}
+	if err := json.Unmarshal(rest[3:3+n], h); err != nil {
+		return nil, ErrMalformedHeader
+	}
+	return data[:len(Magic)+3+n], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealKey encrypts plaintext with AES-256-GCM under a raw key, such as
+// one returned by DeriveKey, and returns the nonce followed by the
+// ciphertext. additionalData is authenticated but not included.
+func SealKey(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := IoRead(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// OpenKey decrypts data produced by SealKey. It returns ErrAuthentication
+// if key or additionalData is wrong or data was tampered with.
+func OpenKey(key, data, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrAuthentication
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,104 @@
+package cipher
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	DefaultKDF = KDFParams{Name: "scrypt", N: 1 << 10, R: 8, P: 1}
+}
+
+func TestSeal(t *testing.T) {
+	IoRead = io.ReadFull
+
+	t.Run("it round trips plaintext with the right key", func(t *testing.T) {
+		data, err := Seal("test_key", []byte(`{"a":"b"}`))
+		assert.Nil(t, err)
+		assert.True(t, IsSealed(data))
+		plain, err := Open("test_key", data)
+		assert.Nil(t, err)
+		assert.Equal(t, `{"a":"b"}`, string(plain))
+	})
+
+	t.Run("it uses a fresh salt and nonce every time", func(t *testing.T) {
+		a, _ := Seal("test_key", []byte("same"))
+		b, _ := Seal("test_key", []byte("same"))
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("it returns error if random source fails", func(t *testing.T) {
+		f := &fakeCipher{err: errors.New("error: unexpected EOF")}
+		IoRead = f.ioread
+		_, err := Seal("test_key", []byte("x"))
+		assert.Equal(t, f.err, err)
+		IoRead = io.ReadFull
+	})
+}
+
+func TestOpen(t *testing.T) {
+	IoRead = io.ReadFull
+	data, err := Seal("test_key", []byte("secret data"))
+	assert.Nil(t, err)
+
+	t.Run("it detects a wrong key", func(t *testing.T) {
+		_, err := Open("wrong_key", data)
+		assert.Equal(t, ErrAuthentication, err)
+	})
+
+	t.Run("it detects modified ciphertext", func(t *testing.T) {
+		tampered := append([]byte(nil), data...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err := Open("test_key", tampered)
+		assert.Equal(t, ErrAuthentication, err)
+	})
+
+	t.Run("it detects a modified header", func(t *testing.T) {
+		tampered := append([]byte(nil), data...)
+		_, preamble, _ := parseHeader(data)
+		tampered[len(preamble)-2] ^= 0x01
+		_, err := Open("test_key", tampered)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it rejects data that is not sealed", func(t *testing.T) {
+		_, err := Open("test_key", []byte("plain text"))
+		assert.Equal(t, ErrNotSealed, err)
+	})
+
+	t.Run("it rejects unknown versions", func(t *testing.T) {
+		future := append([]byte(nil), data...)
+		future[len(Magic)] = FormatVersion + 1
+		_, err := Open("test_key", future)
+		assert.Equal(t, ErrUnsupportedVersion, err)
+	})
+
+	t.Run("it rejects a truncated header", func(t *testing.T) {
+		_, err := Open("test_key", data[:len(Magic)+5])
+		assert.Equal(t, ErrMalformedHeader, err)
+	})
+}
+
+func TestSealKey(t *testing.T) {
+	IoRead = io.ReadFull
+	key := make([]byte, keySize)
+
+	t.Run("it round trips with the same key and additional data", func(t *testing.T) {
+		data, err := SealKey(key, []byte("entry"), []byte("ad"))
+		assert.Nil(t, err)
+		plain, err := OpenKey(key, data, []byte("ad"))
+		assert.Nil(t, err)
+		assert.Equal(t, "entry", string(plain))
+	})
+
+	t.Run("it detects other additional data or a short input", func(t *testing.T) {
+		data, _ := SealKey(key, []byte("entry"), []byte("ad"))
+		_, err := OpenKey(key, data, []byte("other"))
+		assert.Equal(t, ErrAuthentication, err)
+		_, err = OpenKey(key, data[:4], nil)
+		assert.Equal(t, ErrAuthentication, err)
+	})
+}
@@ -0,0 +1,288 @@
+package cipher
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// StreamFormatVersion is the version of the format written by SealStream.
+const StreamFormatVersion = 3
+
+// DefaultChunkSize is the amount of plaintext sealed per chunk.
+const DefaultChunkSize = 64 * 1024
+
+const (
+	noncePrefixSize = 7
+	maxChunkSize    = 16 * 1024 * 1024
+)
+
+// ErrTruncated is returned by the reader of OpenStream when the data ends
+// on a chunk boundary before its final chunk.
+var ErrTruncated = errors.New("cipher: data is truncated")
+
+// StreamHeader is the plaintext preamble of data written by SealStream.
+// The key is derived from a passphrase with KDF when it is set; otherwise
+// KeyName may say where the raw key is kept, such as a vault secret.
+type StreamHeader struct {
+	KDF       *KDFParams `json:"kdf,omitempty"`
+	KeyName   string     `json:"key_name,omitempty"`
+	ChunkSize int        `json:"chunk_size"`
+	Nonce     []byte     `json:"nonce"`
+}
+
+// SealStream writes the header h to w and returns a writer encrypting
+// everything written to it in chunks of h.ChunkSize bytes, or
+// DefaultChunkSize. Every chunk is sealed with AES-256-GCM under key with
+// its index and whether it is the last one in the nonce, so chunks can't
+// be reordered, dropped or cut off undetected. Close writes the final
+// chunk and must be called; it doesn't close w.
+//
+// The layout is the preamble of Seal, with StreamFormatVersion, followed
+// by the chunks, each ChunkSize bytes of plaintext plus a 16-byte tag
+// except the last which may be shorter.
+func SealStream(w io.Writer, key []byte, h StreamHeader) (io.WriteCloser, error) {
+	if h.ChunkSize == 0 {
+		h.ChunkSize = DefaultChunkSize
+	}
+	if h.ChunkSize < 0 || h.ChunkSize > maxChunkSize {
+		return nil, errors.New("cipher: invalid chunk size")
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	h.Nonce = make([]byte, noncePrefixSize)
+	if _, err := IoRead(rand.Reader, h.Nonce); err != nil {
+		return nil, err
+	}
+	preamble, err := marshalPreamble(StreamFormatVersion, h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(preamble); err != nil {
+		return nil, err
+	}
+	return &streamWriter{
+		chunks: chunks{aead: aead, prefix: h.Nonce, ad: preamble},
+		w:      w,
+		buf:    make([]byte, 0, h.ChunkSize),
+	}, nil
+}
+
+// SealStreamWith is like SealStream with a key derived from passphrase
+// with params and a fresh salt, recorded in the header.
+func SealStreamWith(w io.Writer, passphrase string, params KDFParams) (io.WriteCloser, error) {
+	params.Salt = make([]byte, saltSize)
+	if _, err := IoRead(rand.Reader, params.Salt); err != nil {
+		return nil, err
+	}
+	key, err := DeriveKey(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	return SealStream(w, key, StreamHeader{KDF: &params})
+}
+
+// OpenStream reads the header of data written by SealStream from r,
+// gets the key for it from key and returns a reader decrypting the
+// chunks. The reader returns ErrAuthentication if the key is wrong or the
+// data was modified, and ErrTruncated if it was cut short, so its output
+// must not be trusted until it returns io.EOF.
+func OpenStream(r io.Reader, key func(StreamHeader) ([]byte, error)) (io.Reader, error) {
+	var h StreamHeader
+	preamble, err := readPreamble(r, StreamFormatVersion, &h)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Nonce) != noncePrefixSize || h.ChunkSize <= 0 || h.ChunkSize > maxChunkSize {
+		return nil, ErrMalformedHeader
+	}
+	k, err := key(h)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(k)
+	if err != nil {
+		return nil, err
+	}
+	return &streamReader{
+		chunks: chunks{aead: aead, prefix: h.Nonce, ad: preamble},
+		r:      bufio.NewReader(r),
+		buf:    make([]byte, h.ChunkSize+aead.Overhead()),
+		out:    make([]byte, 0, h.ChunkSize),
+	}, nil
+}
+
+// PassphraseKey returns the key function for OpenStream deriving the key
+// of a header with a KDF from passphrase.
+func PassphraseKey(passphrase string) func(StreamHeader) ([]byte, error) {
+	return func(h StreamHeader) ([]byte, error) {
+		if h.KDF == nil {
+			return nil, errors.New("cipher: data is not sealed with a passphrase")
+		}
+		return DeriveKey(passphrase, *h.KDF)
+	}
+}
+
+// IsStream reports whether data starts like the output of SealStream.
+func IsStream(data []byte) bool {
+	return IsSealed(data) && len(data) > len(Magic) && data[len(Magic)] == StreamFormatVersion
+}
+
+// readPreamble is parsePreamble for a reader, returning the raw preamble
+// bytes read.
+func readPreamble(r io.Reader, version byte, h interface{}) ([]byte, error) {
+	start := make([]byte, len(Magic)+3)
+	if _, err := io.ReadFull(r, start); err != nil {
+		return nil, ErrNotSealed
+	}
+	n := int(binary.BigEndian.Uint16(start[len(Magic)+1:]))
+	preamble := make([]byte, len(start)+n)
+	copy(preamble, start)
+	if _, err := io.ReadFull(r, preamble[len(start):]); err != nil {
+		if IsSealed(start) {
+			return nil, ErrMalformedHeader
+		}
+		return nil, ErrNotSealed
+	}
+	return parsePreamble(preamble, version, h)
+}
+
+// chunks seals and opens the chunks of a stream in order.
+type chunks struct {
+	aead   cipher.AEAD
+	prefix []byte
+	ad     []byte
+	index  uint32
+	// wrapped is set once index overflowed, which would reuse nonces
+	wrapped bool
+}
+
+func (c *chunks) nonce(last bool) []byte {
+	nonce := make([]byte, 0, c.aead.NonceSize())
+	nonce = append(nonce, c.prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, c.index)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+func (c *chunks) next() error {
+	if c.wrapped {
+		return errors.New("cipher: stream is too long")
+	}
+	c.index++
+	c.wrapped = c.index == 0
+	return nil
+}
+
+type streamWriter struct {
+	chunks
+	w      io.Writer
+	buf    []byte
+	closed bool
+}
+
+// Write buffers p and seals every chunk that is full once more data
+// follows it, as the last chunk is only known on Close.
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, errors.New("cipher: write to closed stream")
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(s.buf) == cap(s.buf) {
+			if err := s.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (s *streamWriter) flush(last bool) error {
+	if s.wrapped {
+		return errors.New("cipher: stream is too long")
+	}
+	sealed := s.aead.Seal(nil, s.nonce(last), s.buf, s.ad)
+	if _, err := s.w.Write(sealed); err != nil {
+		return err
+	}
+	s.buf = s.buf[:0]
+	return s.next()
+}
+
+// Close seals the final chunk, which is empty for empty plaintext.
+func (s *streamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.flush(true)
+}
+
+type streamReader struct {
+	chunks
+	r     *bufio.Reader
+	buf   []byte
+	out   []byte
+	plain []byte
+	done  bool
+	err   error
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.plain) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.done {
+			return 0, io.EOF
+		}
+		s.err = s.readChunk()
+	}
+	n := copy(p, s.plain)
+	s.plain = s.plain[n:]
+	return n, nil
+}
+
+// readChunk opens the next chunk. A chunk is the last one when nothing
+// follows it.
+func (s *streamReader) readChunk() error {
+	n, err := io.ReadFull(s.r, s.buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	last := n < len(s.buf)
+	if !last {
+		if _, err := s.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if s.wrapped {
+		return ErrAuthentication
+	}
+	// a failed Open clears its output, so it must not overlap buf
+	plain, err := s.aead.Open(s.out[:0], s.nonce(last), s.buf[:n], s.ad)
+	if err != nil {
+		if last {
+			if _, err := s.aead.Open(s.out[:0], s.nonce(false), s.buf[:n], s.ad); err == nil {
+				return ErrTruncated
+			}
+		}
+		return ErrAuthentication
+	}
+	s.plain, s.done = plain, last
+	return s.next()
+}
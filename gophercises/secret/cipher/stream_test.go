@@ -0,0 +1,113 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sealStream(t *testing.T, key, plaintext []byte, chunkSize int) []byte {
+	var buf bytes.Buffer
+	w, err := SealStream(&buf, key, StreamHeader{ChunkSize: chunkSize})
+	assert.Nil(t, err)
+	// write in odd sizes so chunk boundaries fall inside writes
+	for p := plaintext; len(p) > 0; {
+		n := 7
+		if n > len(p) {
+			n = len(p)
+		}
+		w.Write(p[:n])
+		p = p[n:]
+	}
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func openStream(key, data []byte) ([]byte, error) {
+	r, err := OpenStream(bytes.NewReader(data), func(StreamHeader) ([]byte, error) { return key, nil })
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestSealStream(t *testing.T) {
+	key, _ := NewDataKey()
+	plaintext := make([]byte, 1000)
+	rand.Read(plaintext)
+
+	t.Run("it round trips any length", func(t *testing.T) {
+		for _, n := range []int{0, 1, 99, 100, 101, 1000} {
+			data := sealStream(t, key, plaintext[:n], 100)
+			assert.True(t, IsStream(data))
+			got, err := openStream(key, data)
+			assert.Nil(t, err, "length %d", n)
+			assert.Equal(t, plaintext[:n], append([]byte{}, got...), "length %d", n)
+		}
+	})
+
+	data := sealStream(t, key, plaintext, 100)
+	preamble := len(data) - 10*(100+16)
+
+	t.Run("it detects truncation on a chunk boundary", func(t *testing.T) {
+		_, err := openStream(key, data[:preamble+5*(100+16)])
+		assert.Equal(t, ErrTruncated, err)
+	})
+
+	t.Run("it detects truncation inside a chunk", func(t *testing.T) {
+		_, err := openStream(key, data[:len(data)-1])
+		assert.Equal(t, ErrAuthentication, err)
+	})
+
+	t.Run("it detects appended, reordered and modified chunks", func(t *testing.T) {
+		_, err := openStream(key, append(append([]byte{}, data...), data[preamble:preamble+116]...))
+		assert.Equal(t, ErrAuthentication, err)
+
+		swapped := append([]byte{}, data...)
+		copy(swapped[preamble:], data[preamble+116:preamble+232])
+		copy(swapped[preamble+116:], data[preamble:preamble+116])
+		_, err = openStream(key, swapped)
+		assert.Equal(t, ErrAuthentication, err)
+
+		modified := append([]byte{}, data...)
+		modified[len(modified)-20] ^= 1
+		_, err = openStream(key, modified)
+		assert.Equal(t, ErrAuthentication, err)
+	})
+
+	t.Run("it rejects a wrong key", func(t *testing.T) {
+		other, _ := NewDataKey()
+		_, err := openStream(other, data)
+		assert.Equal(t, ErrAuthentication, err)
+	})
+
+	t.Run("it rejects other formats", func(t *testing.T) {
+		sealed, _ := Seal("test_key", []byte("x"))
+		_, err := openStream(key, sealed)
+		assert.Equal(t, ErrUnsupportedVersion, err)
+		_, err = openStream(key, []byte("plain text"))
+		assert.Equal(t, ErrNotSealed, err)
+	})
+}
+
+func TestSealStreamWith(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := SealStreamWith(&buf, "test_key", DefaultKDF)
+	assert.Nil(t, err)
+	io.WriteString(w, "hello")
+	assert.Nil(t, w.Close())
+
+	r, err := OpenStream(bytes.NewReader(buf.Bytes()), PassphraseKey("test_key"))
+	assert.Nil(t, err)
+	got, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(got))
+
+	r, _ = OpenStream(bytes.NewReader(buf.Bytes()), PassphraseKey("wrong"))
+	_, err = ioutil.ReadAll(r)
+	assert.Equal(t, ErrAuthentication, err)
+}
@@ -0,0 +1,110 @@
+package cobra
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gophercises/secret"
+	"gophercises/secret/agent"
+
+	"github.com/spf13/cobra"
+)
+
+var agentTTL time.Duration
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Caches encoding keys so they are only entered once per session",
+}
+
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Runs the key agent in the foreground",
+	Run: func(cmd *cobra.Command, args []string) {
+		socket := agentSocket()
+		l, err := agent.Listen(socket)
+		if err != nil {
+			fmt.Println("Failed to start agent:", err)
+			return
+		}
+		defer os.Remove(socket)
+		s := agent.NewServer(agentTTL)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			<-signals
+			s.Close()
+		}()
+		fmt.Printf("Agent listening on %s, keeping keys for %s.\n", socket, agentTTL)
+		if err := s.Serve(l); err != nil {
+			fmt.Println("Agent stopped:", err)
+		}
+	},
+}
+
+var agentAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Hands the encoding key of the selected namespace to the agent",
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := openStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := secret.CheckNamespace(namespace); err != nil {
+			fmt.Println(err)
+			return
+		}
+		id := vaultID(s, namespace)
+		key, _, err := resolveKey(id)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		// Loading proves the key is right before it is cached.
+		if err := secret.New(key, s, namespace).Load(); err != nil {
+			fmt.Println("Failed to unlock:", err)
+			return
+		}
+		if err := agent.Put(agentSocket(), id, key, agentTTL); err != nil {
+			fmt.Println("Failed to reach agent:", err)
+			return
+		}
+		fmt.Println("Key added to agent.")
+	},
+}
+
+var agentClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Makes the agent forget every key",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := agent.Clear(agentSocket()); err != nil {
+			fmt.Println("Failed to reach agent:", err)
+			return
+		}
+		fmt.Println("Agent cleared.")
+	},
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stops the agent",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := agent.Stop(agentSocket()); err != nil {
+			fmt.Println("Failed to reach agent:", err)
+			return
+		}
+		fmt.Println("Agent stopped.")
+	},
+}
+
+func init() {
+	agentStartCmd.Flags().DurationVar(&agentTTL, "ttl", agent.DefaultTTL, "how long keys are kept")
+	agentAddCmd.Flags().DurationVar(&agentTTL, "ttl", agent.DefaultTTL, "how long this key is kept, at most the agent's own ttl")
+	agentCmd.AddCommand(agentStartCmd, agentAddCmd, agentClearCmd, agentStopCmd)
+	RootCmd.AddCommand(agentCmd)
+}
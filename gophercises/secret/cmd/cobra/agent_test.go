@@ -0,0 +1,65 @@
+package cobra
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gophercises/secret"
+	"gophercises/secret/agent"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgent(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { encodingKey = "" }()
+
+	t.Run("it fails when no agent is running", func(t *testing.T) {
+		agentClearCmd.Run(myCmd, nil)
+		agentStopCmd.Run(myCmd, nil)
+		agentAddCmd.Run(myCmd, nil)
+	})
+
+	t.Run("it adds, clears and stops", func(t *testing.T) {
+		agentTTL = time.Minute
+		done := make(chan struct{})
+		go func() {
+			agentStartCmd.Run(myCmd, nil)
+			close(done)
+		}()
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(agentSocket()); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		encodingKey = "agentkey"
+		nsCreateCmd.Run(myCmd, []string{"agentns"})
+		namespace = "agentns"
+		agentAddCmd.Run(myCmd, nil)
+		encodingKey = ""
+
+		v, err := openVault()
+		assert.Nil(t, err)
+		_, err = v.Entries()
+		assert.Nil(t, err)
+		namespace = "default"
+
+		path, _ := secret.NamespacePath(secretsPath(), "agentns")
+		_, ok, _ := agent.Get(agentSocket(), path)
+		assert.True(t, ok)
+		agentClearCmd.Run(myCmd, nil)
+		_, ok, _ = agent.Get(agentSocket(), path)
+		assert.False(t, ok)
+
+		agentStopCmd.Run(myCmd, nil)
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("agent did not stop")
+		}
+	})
+}
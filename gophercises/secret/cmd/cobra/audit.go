@@ -0,0 +1,67 @@
+package cobra
+
+import (
+	"fmt"
+	"os"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspects the audit log of reads and changes of the vault",
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Shows the audit log",
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		records, err := v.AuditLog()
+		for _, r := range records {
+			printAuditRecord(r)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "warning:", err)
+		}
+	},
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Checks that no audit log entry was modified or removed",
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			exit(1)
+			return
+		}
+		records, err := v.AuditLog()
+		if err != nil {
+			fmt.Println("Audit log verification failed:", err)
+			exit(1)
+			return
+		}
+		fmt.Printf("Audit log intact, %d entries.\n", len(records))
+	},
+}
+
+func printAuditRecord(r secret.AuditRecord) {
+	result := "ok"
+	if r.Error != "" {
+		result = r.Error
+	}
+	fmt.Printf("%d\t%s\t%s@%s\t%s\t%s\t%s\n", r.Seq, formatTime(r.Time), r.User, r.Host, r.Op, r.Key, result)
+}
+
+func init() {
+	auditCmd.AddCommand(auditShowCmd, auditVerifyCmd)
+	RootCmd.AddCommand(auditCmd)
+}
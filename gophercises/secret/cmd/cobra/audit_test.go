@@ -0,0 +1,33 @@
+package cobra
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	var myCmd *cobra.Command
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	t.Run("it shows and verifies the log", func(t *testing.T) {
+		setCmd.Run(myCmd, []string{"audit_api", "value"})
+		auditShowCmd.Run(myCmd, nil)
+		auditVerifyCmd.Run(myCmd, nil)
+		assert.Equal(t, -1, code)
+	})
+
+	t.Run("it fails verification for a tampered log", func(t *testing.T) {
+		path := secretsPath() + ".audit"
+		data, _ := os.ReadFile(path)
+		defer os.WriteFile(path, data, 0600)
+		os.WriteFile(path, data[:len(data)-10], 0600)
+		auditShowCmd.Run(myCmd, nil)
+		auditVerifyCmd.Run(myCmd, nil)
+		assert.Equal(t, 1, code)
+	})
+}
@@ -0,0 +1,33 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackend(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { backend, storePath = "file", "" }()
+
+	for _, b := range []string{"dir", "bolt", "memory"} {
+		t.Run("it keeps secrets in the "+b+" backend", func(t *testing.T) {
+			backend = b
+			setCmd.Run(myCmd, []string{"backend_api", b})
+			getCmd.Run(myCmd, []string{"backend_api"})
+			v, err := openVault()
+			assert.Nil(t, err)
+			value, err := v.Get("backend_api")
+			assert.Nil(t, err)
+			assert.Equal(t, b, value)
+		})
+	}
+
+	t.Run("it fails for an unknown backend", func(t *testing.T) {
+		backend = "s3"
+		_, err := openVault()
+		assert.NotNil(t, err)
+		setCmd.Run(myCmd, []string{"backend_api", "value"})
+	})
+}
@@ -0,0 +1,160 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// exit ends the process with the child's exit code.
+var exit = os.Exit
+
+var (
+	execOnly   []string
+	execPrefix string
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [--only k1,k2] [--prefix APP_] -- command [args...]",
+	Short: "Runs a command with secrets in its environment",
+	Long: `Runs a command with secrets in its environment.
+
+Each key becomes an upper case variable name with every character other
+than letters, digits and underscores replaced by an underscore, so
+"db-password" is passed as DB_PASSWORD, or APP_DB_PASSWORD with
+--prefix APP_. Values are only passed through the environment of the
+child; they are never written to disk or put in its arguments. The
+SECRET_KEY and SECRET_AGENT_SOCK variables are not passed on, so the
+child can't open the vault itself.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+			return
+		}
+		values, err := v.Values()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Something went wrong:", err)
+			exit(1)
+			return
+		}
+		env, err := secretEnv(values, execOnly, execPrefix)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+			return
+		}
+		exit(runWithEnv(args, env))
+	},
+}
+
+// secretEnv maps the selected secrets to NAME=value environment entries.
+func secretEnv(values map[string]string, only []string, prefix string) ([]string, error) {
+	keys := only
+	if len(keys) == 0 {
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+	names := make(map[string]string, len(keys))
+	var env []string
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok {
+			return nil, fmt.Errorf("no value set for %q", key)
+		}
+		name := envName(prefix + key)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("%q and %q both map to %s", other, key, name)
+		}
+		names[name] = key
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+func envName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// vaultEnv are the variables giving access to the whole vault.
+var vaultEnv = []string{"SECRET_KEY", "SECRET_AGENT_SOCK"}
+
+// childEnviron returns the current environment without vaultEnv.
+func childEnviron() []string {
+	var env []string
+outer:
+	for _, kv := range os.Environ() {
+		for _, name := range vaultEnv {
+			if strings.HasPrefix(kv, name+"=") {
+				continue outer
+			}
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// runWithEnv runs args with env added to the current environment, less
+// vaultEnv, passing stdio and signals through, and returns its exit code.
+func runWithEnv(args []string, env []string) int {
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = append(childEnviron(), env...)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := child.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 127
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			child.Process.Signal(sig)
+		}
+	}()
+	err := child.Wait()
+	signal.Stop(signals)
+	close(signals)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code := exitErr.ExitCode(); code >= 0 {
+			return code
+		}
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().StringSliceVar(&execOnly, "only", nil, "only pass these keys")
+	execCmd.Flags().StringVar(&execPrefix, "prefix", "", "prefix for every variable name")
+	RootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,67 @@
+package cobra
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExec(t *testing.T) {
+	var myCmd *cobra.Command
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() {
+		exit, execOnly, execPrefix = os.Exit, nil, ""
+	}()
+	setCmd.Run(myCmd, []string{"db-password", "hunter2"})
+
+	t.Run("it passes secrets in the environment", func(t *testing.T) {
+		execPrefix = "APP_"
+		execCmd.Run(myCmd, []string{"sh", "-c", `test "$APP_DB_PASSWORD" = hunter2`})
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("it propagates the exit code", func(t *testing.T) {
+		execCmd.Run(myCmd, []string{"sh", "-c", "exit 3"})
+		assert.Equal(t, 3, code)
+	})
+
+	t.Run("it only passes the selected keys", func(t *testing.T) {
+		execOnly, execPrefix = []string{"db-password"}, ""
+		execCmd.Run(myCmd, []string{"sh", "-c", `test "$DB_PASSWORD" = hunter2 && test -z "$TWIT_API"`})
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("it keeps the vault key from the child", func(t *testing.T) {
+		os.Setenv("SECRET_KEY", "")
+		os.Setenv("SECRET_AGENT_SOCK", "/tmp/agent.sock")
+		defer os.Unsetenv("SECRET_KEY")
+		defer os.Unsetenv("SECRET_AGENT_SOCK")
+		code = runWithEnv([]string{"sh", "-c", `test "${SECRET_KEY-unset}" = unset && test -z "$SECRET_AGENT_SOCK" && test -n "$HOME"`}, nil)
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("it fails for a missing key or command", func(t *testing.T) {
+		execOnly = []string{"missing"}
+		execCmd.Run(myCmd, []string{"true"})
+		assert.Equal(t, 1, code)
+		execOnly = nil
+		execCmd.Run(myCmd, []string{"/nonexistent/command"})
+		assert.Equal(t, 127, code)
+	})
+}
+
+func TestSecretEnv(t *testing.T) {
+	t.Run("it maps keys to variable names", func(t *testing.T) {
+		env, err := secretEnv(map[string]string{"db.password": "a", "1st": "b"}, nil, "")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"_1ST=b", "DB_PASSWORD=a"}, env)
+	})
+
+	t.Run("it rejects keys mapping to the same name", func(t *testing.T) {
+		_, err := secretEnv(map[string]string{"db-password": "a", "db_password": "b"}, nil, "")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,68 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+	exportYes    bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Writes every secret in plaintext as dotenv, JSON or YAML",
+	Run: func(cmd *cobra.Command, args []string) {
+		format := exportFormat
+		if format == "" {
+			format = secret.FormatFromPath(exportOutput)
+		}
+		if !exportYes && !confirm("This writes your secrets in plaintext. Continue?") {
+			fmt.Println("Aborted.")
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		values, err := v.Values()
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		var w io.Writer = os.Stdout
+		if exportOutput != "" && exportOutput != "-" {
+			f, err := os.OpenFile(exportOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				fmt.Println("Failed to export:", err)
+				return
+			}
+			defer f.Close()
+			w = f
+		}
+		err = secret.EncodeValues(w, format, values)
+		if err != nil {
+			fmt.Println("Failed to export:", err)
+			return
+		}
+		if w != os.Stdout {
+			fmt.Printf("Exported %d secrets to %s.\n", len(values), exportOutput)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "", "output format: "+strings.Join(secret.Formats, ", ")+" (default from the output file name, else dotenv)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file to write to, created with 0600 permissions (default stdout)")
+	exportCmd.Flags().BoolVarP(&exportYes, "yes", "y", false, "don't ask for confirmation")
+	RootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,64 @@
+package cobra
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	var myCmd *cobra.Command
+	dir, _ := ioutil.TempDir("", "export")
+	defer os.RemoveAll(dir)
+	defer func() {
+		exportFormat, exportOutput, exportYes, stdin = "", "", false, os.Stdin
+	}()
+	setCmd.Run(myCmd, []string{"export_api", "exported value"})
+
+	t.Run("it asks before writing plaintext", func(t *testing.T) {
+		exportOutput = filepath.Join(dir, "aborted.env")
+		isTerminal = func() bool { return true }
+		defer func() { isTerminal = func() bool { return false } }()
+		stdin = strings.NewReader("n\n")
+		exportCmd.Run(myCmd, nil)
+		_, err := os.Stat(exportOutput)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("it keeps the prompt out of stdout", func(t *testing.T) {
+		exportOutput = ""
+		isTerminal = func() bool { return true }
+		defer func() { isTerminal = func() bool { return false } }()
+		stdin = strings.NewReader("n\n")
+		out := captureStdout(func() { exportCmd.Run(myCmd, nil) })
+		assert.NotContains(t, out, "Continue?")
+	})
+
+	for _, name := range []string{"secrets.env", "secrets.json", "secrets.yaml"} {
+		t.Run("it exports "+name+" with owner only permissions", func(t *testing.T) {
+			exportOutput, exportYes = filepath.Join(dir, name), true
+			exportCmd.Run(myCmd, nil)
+
+			info, err := os.Stat(exportOutput)
+			assert.Nil(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+			f, _ := os.Open(exportOutput)
+			defer f.Close()
+			values, err := secret.DecodeValues(f, secret.FormatFromPath(name))
+			assert.Nil(t, err)
+			assert.Equal(t, "exported value", values["export_api"])
+		})
+	}
+
+	t.Run("it fails for an unknown format", func(t *testing.T) {
+		exportFormat, exportOutput = "xml", ""
+		exportCmd.Run(myCmd, nil)
+	})
+}
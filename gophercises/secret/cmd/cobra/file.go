@@ -0,0 +1,135 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fileOutput string
+	fileKey    string
+)
+
+var encryptFileCmd = &cobra.Command{
+	Use:   "encrypt-file <file> [-o file.enc] [--file-key name]",
+	Short: "Encrypts a file of any size with the vault",
+	Long: `Encrypts a file in authenticated chunks, so large files are streamed and
+a truncated or modified file is detected on decryption.
+
+The key is derived from the encoding key, or with --file-key is a random
+key kept in the vault as the secret of that name, created on first use.
+Vaults shared with recipients need a file key. Use "-" for stdin.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out := fileOutput
+		if out == "" {
+			if args[0] == "-" {
+				out = "-"
+			} else {
+				out = args[0] + ".enc"
+			}
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = streamFile(args[0], out, func(w io.Writer, r io.Reader) error {
+			return v.EncryptFile(w, r, fileKey)
+		})
+		if err != nil {
+			fmt.Println("Failed to encrypt:", err)
+			return
+		}
+		if out != "-" {
+			fmt.Printf("Encrypted %s to %s.\n", args[0], out)
+		}
+	},
+}
+
+var decryptFileCmd = &cobra.Command{
+	Use:   "decrypt-file <file.enc> [-o file]",
+	Short: "Decrypts a file written by encrypt-file",
+	Long: `Decrypts a file written by encrypt-file. The output is only put in place
+once the whole file has been authenticated. Use "-" for stdin or stdout;
+output written to stdout can't be taken back when the file turns out to
+be modified.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out := fileOutput
+		if out == "" {
+			if args[0] == "-" {
+				out = "-"
+			} else if out = strings.TrimSuffix(args[0], ".enc"); out == args[0] {
+				fmt.Println("Give the output file with -o.")
+				return
+			}
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = streamFile(args[0], out, v.DecryptFile)
+		if err != nil {
+			fmt.Println("Failed to decrypt:", err)
+			return
+		}
+		if out != "-" {
+			fmt.Printf("Decrypted %s to %s.\n", args[0], out)
+		}
+	},
+}
+
+// streamFile runs f from the file in to a temporary file with 0600
+// permissions next to out, and renames it over out only if f succeeds.
+// "-" stands for stdin or stdout.
+func streamFile(in, out string, f func(w io.Writer, r io.Reader) error) error {
+	var r io.Reader = os.Stdin
+	if in != "-" {
+		src, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		r = src
+	}
+	if out == "-" {
+		return f(os.Stdout, r)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(out), "."+filepath.Base(out)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := f(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), out)
+}
+
+func init() {
+	encryptFileCmd.Flags().StringVarP(&fileOutput, "output", "o", "", "the encrypted file (default <file>.enc)")
+	encryptFileCmd.Flags().StringVar(&fileKey, "file-key", "", "encrypt with the random key kept as this secret, creating it if needed")
+	decryptFileCmd.Flags().StringVarP(&fileOutput, "output", "o", "", "the decrypted file (default <file> without .enc)")
+	RootCmd.AddCommand(encryptFileCmd)
+	RootCmd.AddCommand(decryptFileCmd)
+}
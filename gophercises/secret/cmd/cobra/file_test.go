@@ -0,0 +1,49 @@
+package cobra
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptFile(t *testing.T) {
+	var myCmd *cobra.Command
+	dir, _ := ioutil.TempDir("", "files")
+	defer func() {
+		fileOutput, fileKey = "", ""
+		os.RemoveAll(dir)
+	}()
+	plain := filepath.Join(dir, "notes.txt")
+	ioutil.WriteFile(plain, []byte("meeting notes"), 0644)
+
+	for _, key := range []string{"", "notes_key"} {
+		fileOutput, fileKey = "", key
+		encryptFileCmd.Run(myCmd, []string{plain})
+		data, err := ioutil.ReadFile(plain + ".enc")
+		assert.Nil(t, err)
+		assert.NotContains(t, string(data), "meeting")
+
+		os.Remove(plain)
+		decryptFileCmd.Run(myCmd, []string{plain + ".enc"})
+		data, err = ioutil.ReadFile(plain)
+		assert.Nil(t, err)
+		assert.Equal(t, "meeting notes", string(data))
+		info, _ := os.Stat(plain)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	t.Run("it leaves no output for a truncated file", func(t *testing.T) {
+		data, _ := ioutil.ReadFile(plain + ".enc")
+		ioutil.WriteFile(plain+".enc", data[:len(data)-3], 0600)
+		fileOutput = filepath.Join(dir, "out.txt")
+		decryptFileCmd.Run(myCmd, []string{plain + ".enc"})
+		_, err := os.Stat(fileOutput)
+		assert.True(t, os.IsNotExist(err))
+		files, _ := ioutil.ReadDir(dir)
+		assert.Len(t, files, 2)
+	})
+}
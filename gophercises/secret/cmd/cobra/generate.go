@@ -0,0 +1,85 @@
+package cobra
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	generateLength  int
+	generateCharset string
+	generateMaxAge  string
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate <key>",
+	Short: "Generates a random secret and stores it",
+	Long: `Generates a random value for a key and stores it. With --charset words
+the length is a number of words from the BIP 39 list, joined by "-".
+
+With --max-age the key gets a rotation policy and "secret rotate --due"
+regenerates it once it is older than that.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		maxAge, err := parseMaxAge(generateMaxAge)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		opts, err := setOptions(time.Now())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		policy := secret.Policy{Charset: generateCharset, Length: generateLength, MaxAge: maxAge}
+		value, err := policy.Generate()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = v.Set(args[0], value, append(opts, secret.WithPolicy(policy))...)
+		if err != nil {
+			fmt.Println("Failed to store the value:", err)
+			return
+		}
+		fmt.Println("Value generated successfully!")
+	},
+}
+
+// parseMaxAge accepts a duration such as "720h" or a number of days such
+// as "30d". An empty string means no rotation.
+func parseMaxAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid max age %q, use a duration such as 720h or a number of days such as 30d", s)
+}
+
+func init() {
+	generateCmd.Flags().IntVarP(&generateLength, "length", "l", 32, "the number of characters, or of words with --charset words")
+	generateCmd.Flags().StringVarP(&generateCharset, "charset", "c", secret.CharsetAlnum, "the characters to draw from: alnum, hex, base64 or words")
+	generateCmd.Flags().StringVar(&generateMaxAge, "max-age", "", "rotate the secret once it is older than this, such as 720h or 30d")
+	generateCmd.Flags().StringVarP(&setDescription, "description", "d", "", "a description of the secret")
+	generateCmd.Flags().StringSliceVarP(&setTags, "tag", "t", nil, "tags for the secret, replacing any existing tags")
+	generateCmd.Flags().StringVarP(&setExpires, "expires", "e", "", "when the secret expires: a duration, a date, an RFC 3339 time or never")
+	RootCmd.AddCommand(generateCmd)
+}
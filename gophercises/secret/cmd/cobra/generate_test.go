@@ -0,0 +1,75 @@
+package cobra
+
+import (
+	"testing"
+	"time"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		generateLength, generateCharset, generateMaxAge = 32, secret.CharsetAlnum, ""
+		rotateDue = false
+	}()
+
+	t.Run("it stores a generated value with its policy", func(t *testing.T) {
+		generateLength, generateCharset, generateMaxAge = 20, secret.CharsetHex, "30d"
+		generateCmd.Run(myCmd, []string{"gen_api"})
+
+		v, _ := openVault()
+		e, err := v.GetEntry("gen_api")
+		assert.Nil(t, err)
+		assert.Len(t, e.Value, 20)
+		assert.Equal(t, secret.Policy{Charset: secret.CharsetHex, Length: 20, MaxAge: 30 * 24 * time.Hour}, *e.Rotation)
+	})
+
+	t.Run("it rejects an unknown charset", func(t *testing.T) {
+		generateCharset, generateMaxAge = "emoji", ""
+		generateCmd.Run(myCmd, []string{"gen_bad"})
+		v, _ := openVault()
+		_, err := v.GetEntry("gen_bad")
+		assert.Equal(t, secret.ErrNoValue, err)
+	})
+
+	t.Run("it rotates named keys", func(t *testing.T) {
+		v, _ := openVault()
+		before, _ := v.GetEntry("gen_api")
+		rotateCmd.Run(myCmd, []string{"gen_api"})
+		after, _ := v.GetEntry("gen_api")
+		assert.Equal(t, before.Version+1, after.Version)
+		assert.NotEqual(t, before.Value, after.Value)
+	})
+
+	t.Run("it leaves keys that aren't due", func(t *testing.T) {
+		rotateDue = true
+		v, _ := openVault()
+		before, _ := v.GetEntry("gen_api")
+		rotateCmd.Run(myCmd, nil)
+		after, _ := v.GetEntry("gen_api")
+		assert.Equal(t, before.Version, after.Version)
+	})
+}
+
+func TestParseMaxAge(t *testing.T) {
+	d, err := parseMaxAge("30d")
+	assert.Nil(t, err)
+	assert.Equal(t, 30*24*time.Hour, d)
+
+	d, err = parseMaxAge("12h")
+	assert.Nil(t, err)
+	assert.Equal(t, 12*time.Hour, d)
+
+	d, err = parseMaxAge("")
+	assert.Nil(t, err)
+	assert.Zero(t, d)
+
+	_, err = parseMaxAge("monthly")
+	assert.NotNil(t, err)
+	_, err = parseMaxAge("-1d")
+	assert.NotNil(t, err)
+}
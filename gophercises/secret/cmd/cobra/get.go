@@ -1,28 +1,78 @@
 package cobra
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"gophercises/secret"
 
 	"github.com/spf13/cobra"
 )
 
+var getMeta bool
+
 var getCmd = &cobra.Command{
-	Use:   "get",
+	Use:   "get <key>",
 	Short: "Gets a secret in your secret storage",
+	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		v := secret.File(encodingKey, secretsPath())
-		key := args[0]
-		value, err := v.Get(key)
+		v, err := openVault()
 		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		key := args[0]
+		e, err := v.GetEntry(key)
+		if errors.Is(err, secret.ErrNoValue) {
 			fmt.Println("no value set")
 			return
+		} else if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if e.Expired(time.Now()) {
+			fmt.Fprintf(os.Stderr, "warning: %s expired on %s\n", key, e.Expires.Format(time.RFC3339))
+		}
+		fmt.Printf("%s = %s\n", key, e.Value)
+		if getMeta {
+			printMeta(e)
 		}
-		fmt.Printf("%s = %s\n", key, value)
 	},
 }
 
+func printMeta(e secret.Entry) {
+	fmt.Printf("  created:     %s\n", formatTime(e.Created))
+	fmt.Printf("  updated:     %s\n", formatTime(e.Updated))
+	if e.Description != "" {
+		fmt.Printf("  description: %s\n", e.Description)
+	}
+	if len(e.Tags) > 0 {
+		fmt.Printf("  tags:        %s\n", strings.Join(e.Tags, ", "))
+	}
+	if e.Expires != nil {
+		fmt.Printf("  expires:     %s\n", formatTime(*e.Expires))
+	}
+	if p := e.Rotation; p != nil {
+		fmt.Printf("  generated:   %d %s\n", p.Length, p.Charset)
+		if p.MaxAge > 0 {
+			fmt.Printf("  rotation:    every %s, due %s\n", p.MaxAge, formatTime(e.Updated.Add(p.MaxAge)))
+		}
+	}
+}
+
+// formatTime formats t for display; secrets from older vaults have no
+// timestamps.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format(time.RFC3339)
+}
+
 func init() {
+	getCmd.Flags().BoolVarP(&getMeta, "meta", "m", false, "also show the metadata of the secret")
 	RootCmd.AddCommand(getCmd)
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGet(t *testing.T) {
@@ -16,4 +17,29 @@ func TestGet(t *testing.T) {
 	t.Run("it gives value of key if key is present", func(t *testing.T) {
 		getCmd.Run(myCmd, []string{"twit_api2"})
 	})
+
+	t.Run("it shows metadata and warns about expired secrets", func(t *testing.T) {
+		setExpires = "-1h"
+		setCmd.Run(myCmd, []string{"twit_expired", "oldvalue"})
+		setExpires = ""
+		getMeta = true
+		getCmd.Run(myCmd, []string{"twit_expired"})
+		getMeta = false
+	})
+
+	t.Run("it reports a wrong key rather than a missing value", func(t *testing.T) {
+		setCmd.Run(myCmd, []string{"twit_api1", "newvalue"})
+		encodingKey = "wrongkey"
+		defer func() { encodingKey = "" }()
+		for _, c := range []*cobra.Command{getCmd, historyCmd, removeCmd, setCmd} {
+			out := captureStdout(func() { c.Run(myCmd, []string{"twit_api1", "value"}) })
+			assert.Contains(t, out, "authentication", c.Name())
+		}
+	})
+	t.Run("it refuses missing arguments", func(t *testing.T) {
+		assert.NotNil(t, getCmd.Args(getCmd, nil))
+		assert.NotNil(t, setCmd.Args(setCmd, []string{"key"}))
+		assert.NotNil(t, removeCmd.Args(removeCmd, nil))
+		assert.Nil(t, setCmd.Args(setCmd, []string{"key", "value"}))
+	})
 }
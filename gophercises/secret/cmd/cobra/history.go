@@ -0,0 +1,50 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var historyValues bool
+
+var historyCmd = &cobra.Command{
+	Use:   "history <key>",
+	Short: "Shows the retained versions of a secret",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		e, err := v.GetEntry(args[0])
+		if errors.Is(err, secret.ErrNoValue) {
+			fmt.Println("no value set")
+			return
+		} else if err != nil {
+			fmt.Println(err)
+			return
+		}
+		printVersion(e.Version, formatTime(e.Updated)+" (current)", e.Value)
+		for _, old := range e.History {
+			printVersion(old.Version, formatTime(old.Updated), old.Value)
+		}
+	},
+}
+
+func printVersion(version int, updated, value string) {
+	if historyValues {
+		fmt.Printf("%d\t%s\t%s\n", version, updated, value)
+		return
+	}
+	fmt.Printf("%d\t%s\n", version, updated)
+}
+
+func init() {
+	historyCmd.Flags().BoolVar(&historyValues, "values", false, "also show the value of every version")
+	RootCmd.AddCommand(historyCmd)
+}
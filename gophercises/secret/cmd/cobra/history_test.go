@@ -0,0 +1,67 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	var myCmd *cobra.Command
+
+	t.Run("it shows the versions of a key", func(t *testing.T) {
+		setCmd.Run(myCmd, []string{"hist_api", "one"})
+		setCmd.Run(myCmd, []string{"hist_api", "two"})
+		historyCmd.Run(myCmd, []string{"hist_api"})
+		historyValues = true
+		historyCmd.Run(myCmd, []string{"hist_api"})
+		historyValues = false
+	})
+
+	t.Run("it fails for a missing key", func(t *testing.T) {
+		historyCmd.Run(myCmd, []string{"hist_missing"})
+	})
+}
+
+func TestRollback(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { rollbackVersion = 0 }()
+
+	t.Run("it restores a previous version", func(t *testing.T) {
+		setCmd.Run(myCmd, []string{"roll_api", "one"})
+		setCmd.Run(myCmd, []string{"roll_api", "two"})
+		rollbackVersion = 1
+		rollbackCmd.Run(myCmd, []string{"roll_api"})
+
+		v, _ := openVault()
+		e, err := v.GetEntry("roll_api")
+		assert.Nil(t, err)
+		assert.Equal(t, "one", e.Value)
+		assert.Equal(t, 3, e.Version)
+	})
+
+	t.Run("it fails for a version that isn't retained", func(t *testing.T) {
+		rollbackVersion = 42
+		rollbackCmd.Run(myCmd, []string{"roll_api"})
+	})
+}
+
+func TestRetention(t *testing.T) {
+	var myCmd *cobra.Command
+
+	t.Run("it shows and sets the retention", func(t *testing.T) {
+		retentionCmd.Run(myCmd, nil)
+		retentionCmd.Run(myCmd, []string{"2"})
+		v, _ := openVault()
+		n, err := v.Retention()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+		retentionCmd.Run(myCmd, []string{"5"})
+	})
+
+	t.Run("it rejects invalid counts", func(t *testing.T) {
+		retentionCmd.Run(myCmd, []string{"many"})
+		retentionCmd.Run(myCmd, []string{"-1"})
+	})
+}
@@ -0,0 +1,90 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFormat     string
+	importOnConflict string
+	importPrune      bool
+	importYes        bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Imports secrets from a dotenv, JSON or YAML file",
+	Long: `Imports secrets from a dotenv, JSON or YAML file, or stdin if no file is given.
+
+Keys that already exist are resolved with --on-conflict:
+  merge      take the imported value, keeping the metadata (default)
+  skip       keep the current value
+  overwrite  take the imported value, dropping the metadata
+
+Keys that aren't imported are kept, unless --prune removes them.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		policy, err := secret.ParseConflictPolicy(importOnConflict)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		prune := importPrune && !importYes
+		var r io.Reader = stdin
+		file := ""
+		if len(args) > 0 && args[0] != "-" {
+			file = args[0]
+			f, err := os.Open(file)
+			if err != nil {
+				fmt.Println("Failed to import:", err)
+				return
+			}
+			defer f.Close()
+			r = f
+		} else if prune {
+			// the answer would have to come after the input on stdin
+			fmt.Println("Importing from stdin with --prune needs --yes.")
+			return
+		}
+		format := importFormat
+		if format == "" {
+			format = secret.FormatFromPath(file)
+		}
+		values, err := secret.DecodeValues(r, format)
+		if err != nil {
+			fmt.Println("Failed to import:", err)
+			return
+		}
+		if prune && !confirm("Remove every secret that isn't in the import?") {
+			fmt.Println("Aborted.")
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		res, err := v.Import(values, policy, importPrune)
+		if err != nil {
+			fmt.Println("Failed to import:", err)
+			return
+		}
+		fmt.Printf("Imported: %d added, %d updated, %d skipped, %d removed.\n",
+			len(res.Added), len(res.Updated), len(res.Skipped), len(res.Removed))
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "input format: "+strings.Join(secret.Formats, ", ")+" (default from the file name, else dotenv)")
+	importCmd.Flags().StringVar(&importOnConflict, "on-conflict", string(secret.Merge), "what to do with existing keys: merge, skip or overwrite")
+	importCmd.Flags().BoolVar(&importPrune, "prune", false, "remove secrets that aren't in the import")
+	importCmd.Flags().BoolVarP(&importYes, "yes", "y", false, "don't ask for confirmation")
+	RootCmd.AddCommand(importCmd)
+}
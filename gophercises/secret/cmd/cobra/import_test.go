@@ -0,0 +1,91 @@
+package cobra
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImport(t *testing.T) {
+	var myCmd *cobra.Command
+	dir, _ := ioutil.TempDir("", "import")
+	defer os.RemoveAll(dir)
+	defer func() {
+		importFormat, importOnConflict, importPrune, importYes, stdin = "", "merge", false, false, os.Stdin
+	}()
+
+	t.Run("it imports a file with the merge policy", func(t *testing.T) {
+		setCmd.Run(myCmd, []string{"import_a", "old"})
+		path := filepath.Join(dir, "in.json")
+		ioutil.WriteFile(path, []byte(`{"import_a":"new","import_b":"b"}`), 0600)
+		importCmd.Run(myCmd, []string{path})
+
+		v, _ := openVault()
+		values, _ := v.Values()
+		assert.Equal(t, "new", values["import_a"])
+		assert.Equal(t, "b", values["import_b"])
+	})
+
+	t.Run("it imports from stdin with the skip policy", func(t *testing.T) {
+		importOnConflict, importFormat = "skip", "dotenv"
+		stdin = strings.NewReader("import_a=skipped\nimport_c=c\n")
+		importCmd.Run(myCmd, nil)
+
+		v, _ := openVault()
+		values, _ := v.Values()
+		assert.Equal(t, "new", values["import_a"])
+		assert.Equal(t, "c", values["import_c"])
+	})
+
+	t.Run("it fails for an unknown policy or bad input", func(t *testing.T) {
+		importOnConflict = "replace"
+		importCmd.Run(myCmd, nil)
+		importOnConflict = "merge"
+		stdin = strings.NewReader("not dotenv\n")
+		importCmd.Run(myCmd, nil)
+		importCmd.Run(myCmd, []string{filepath.Join(dir, "missing.env")})
+	})
+
+	t.Run("it keeps unrelated keys when overwriting", func(t *testing.T) {
+		importOnConflict, importFormat = "overwrite", "dotenv"
+		stdin = strings.NewReader("import_b=overwritten\n")
+		importCmd.Run(myCmd, nil)
+		v, _ := openVault()
+		values, _ := v.Values()
+		assert.Equal(t, "overwritten", values["import_b"])
+		assert.Contains(t, values, "import_a")
+	})
+
+	t.Run("it asks before pruning the vault", func(t *testing.T) {
+		importPrune, importFormat = true, "yaml"
+		path := filepath.Join(dir, "in.yaml")
+		ioutil.WriteFile(path, []byte("only_key: value\n"), 0600)
+		isTerminal = func() bool { return true }
+		stdin = strings.NewReader("n\n")
+		importCmd.Run(myCmd, []string{path})
+		isTerminal = func() bool { return false }
+		v, _ := openVault()
+		values, _ := v.Values()
+		assert.Contains(t, values, "import_a")
+	})
+
+	t.Run("it needs --yes to prune from stdin", func(t *testing.T) {
+		importPrune, importFormat = true, "dotenv"
+		stdin = strings.NewReader("only_key=value\n")
+		importCmd.Run(myCmd, nil)
+		v, _ := openVault()
+		values, _ := v.Values()
+		assert.Contains(t, values, "import_a")
+
+		importYes = true
+		stdin = strings.NewReader("only_key=value\n")
+		importCmd.Run(myCmd, nil)
+		values, _ = v.Values()
+		assert.Equal(t, map[string]string{"only_key": "value"}, values)
+	})
+}
@@ -0,0 +1,137 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gophercises/secret/agent"
+	"gophercises/secret/cipher"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/term"
+)
+
+var (
+	keyFile      string
+	keyFD        int
+	identityFile string
+	// keyFromFD remembers the key read from --key-fd, which can only be
+	// read once.
+	keyFromFD *string
+)
+
+// isTerminal reports whether the key can be prompted for on stdin.
+var isTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// readPassword reads a line from the terminal without echoing it.
+var readPassword = func() ([]byte, error) {
+	return term.ReadPassword(int(os.Stdin.Fd()))
+}
+
+// resolveKey finds the encoding key of the vault at vaultPath, trying in
+// order: --key, --key-file, --key-fd, the SECRET_KEY environment variable,
+// the agent, and finally a prompt if stdin is a terminal, which it reports
+// with prompted. Without any source the empty key is used, as before these
+// sources existed.
+func resolveKey(vaultPath string) (key string, prompted bool, err error) {
+	switch {
+	case encodingKey != "":
+		return encodingKey, false, nil
+	case keyFile != "":
+		data, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return "", false, err
+		}
+		return trimNewline(string(data)), false, nil
+	case keyFD >= 0:
+		key, err := readKeyFD(keyFD)
+		return key, false, err
+	}
+	if key := os.Getenv("SECRET_KEY"); key != "" {
+		return key, false, nil
+	}
+	if key, ok, err := agent.Get(agentSocket(), vaultPath); err == nil && ok {
+		return key, false, nil
+	}
+	if !isTerminal() {
+		return "", false, nil
+	}
+	key, err = promptKey(vaultPath)
+	if err != nil {
+		return "", false, err
+	}
+	return key, true, nil
+}
+
+func readKeyFD(fd int) (string, error) {
+	if keyFromFD != nil {
+		return *keyFromFD, nil
+	}
+	f := os.NewFile(uintptr(fd), "key-fd")
+	if f == nil {
+		return "", fmt.Errorf("invalid --key-fd %d", fd)
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	key := trimNewline(string(data))
+	keyFromFD = &key
+	return key, nil
+}
+
+func promptKey(vaultPath string) (string, error) {
+	fmt.Fprintf(os.Stderr, "Encoding key for %s: ", vaultPath)
+	key, err := readPassword()
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(key) == 0 {
+		return "", errors.New("no encoding key given")
+	}
+	return string(key), nil
+}
+
+func trimNewline(s string) string {
+	return strings.TrimRight(s, "\r\n")
+}
+
+// identityPath returns --identity, else ~/.secret-identity.
+func identityPath() string {
+	if identityFile != "" {
+		return identityFile
+	}
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".secret-identity")
+}
+
+// loadIdentity reads the identity file. Without --identity a missing
+// default file means there is no identity.
+func loadIdentity() (*cipher.Identity, error) {
+	data, err := ioutil.ReadFile(identityPath())
+	if os.IsNotExist(err) && identityFile == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cipher.ParseIdentity(string(data))
+}
+
+// agentSocket returns the socket of the key agent: SECRET_AGENT_SOCK if
+// set, else ~/.secret-agent.sock.
+func agentSocket() string {
+	if socket := os.Getenv("SECRET_AGENT_SOCK"); socket != "" {
+		return socket
+	}
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".secret-agent.sock")
+}
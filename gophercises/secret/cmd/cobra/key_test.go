@@ -0,0 +1,126 @@
+package cobra
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"gophercises/secret"
+	"gophercises/secret/agent"
+	"gophercises/secret/cipher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveKey(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "key")
+	defer os.RemoveAll(dir)
+	defer func() {
+		encodingKey, keyFile, keyFD, keyFromFD = "", "", -1, nil
+		isTerminal = func() bool { return false }
+		os.Unsetenv("SECRET_KEY")
+	}()
+
+	t.Run("it prefers the key flag", func(t *testing.T) {
+		encodingKey = "flagkey"
+		os.Setenv("SECRET_KEY", "envkey")
+		key, _, err := resolveKey("/vault")
+		assert.Nil(t, err)
+		assert.Equal(t, "flagkey", key)
+		encodingKey = ""
+	})
+
+	t.Run("it reads the key file without the trailing newline", func(t *testing.T) {
+		keyFile = filepath.Join(dir, "key")
+		ioutil.WriteFile(keyFile, []byte("filekey\n"), 0600)
+		key, _, err := resolveKey("/vault")
+		assert.Nil(t, err)
+		assert.Equal(t, "filekey", key)
+
+		keyFile = filepath.Join(dir, "missing")
+		_, _, err = resolveKey("/vault")
+		assert.NotNil(t, err)
+		keyFile = ""
+	})
+
+	t.Run("it reads the key from a file descriptor once", func(t *testing.T) {
+		r, w, _ := os.Pipe()
+		w.Write([]byte("fdkey\n"))
+		w.Close()
+		// readKeyFD closes the descriptor, so hand it a copy r doesn't
+		// close again once the number is reused
+		keyFD, _ = syscall.Dup(int(r.Fd()))
+		r.Close()
+		key, _, err := resolveKey("/vault")
+		assert.Nil(t, err)
+		assert.Equal(t, "fdkey", key)
+		key, _, _ = resolveKey("/vault")
+		assert.Equal(t, "fdkey", key)
+		keyFD, keyFromFD = -1, nil
+	})
+
+	t.Run("it reads the environment", func(t *testing.T) {
+		os.Setenv("SECRET_KEY", "envkey")
+		key, _, err := resolveKey("/vault")
+		assert.Nil(t, err)
+		assert.Equal(t, "envkey", key)
+		os.Unsetenv("SECRET_KEY")
+	})
+
+	t.Run("it uses the empty key when nothing else is available", func(t *testing.T) {
+		key, _, err := resolveKey("/vault")
+		assert.Nil(t, err)
+		assert.Equal(t, "", key)
+	})
+
+	t.Run("it prompts on a terminal", func(t *testing.T) {
+		isTerminal = func() bool { return true }
+		readPassword = func() ([]byte, error) { return []byte("typedkey"), nil }
+		key, prompted, err := resolveKey("/vault")
+		assert.Nil(t, err)
+		assert.True(t, prompted)
+		assert.Equal(t, "typedkey", key)
+	})
+}
+
+func TestPromptedKeyCaching(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "key")
+	defer os.RemoveAll(dir)
+	defer func() {
+		storePath = ""
+		isTerminal = func() bool { return false }
+	}()
+	storePath = filepath.Join(dir, "vault")
+	assert.Nil(t, secret.File("typedkey", storePath).Set("api", "value"))
+	l, err := agent.Listen(agentSocket())
+	assert.Nil(t, err)
+	s := agent.NewServer(time.Minute)
+	go s.Serve(l)
+	defer s.Close()
+	isTerminal = func() bool { return true }
+
+	t.Run("it doesn't cache a key that doesn't open the vault", func(t *testing.T) {
+		readPassword = func() ([]byte, error) { return []byte("typo"), nil }
+		_, err := openVault()
+		assert.Equal(t, cipher.ErrAuthentication, err)
+		_, ok, _ := agent.Get(agentSocket(), storePath)
+		assert.False(t, ok)
+	})
+
+	t.Run("it caches the key in the agent once it opens the vault", func(t *testing.T) {
+		readPassword = func() ([]byte, error) { return []byte("typedkey"), nil }
+		_, err := openVault()
+		assert.Nil(t, err)
+
+		readPassword = func() ([]byte, error) { return nil, errors.New("should not prompt") }
+		v, err := openVault()
+		assert.Nil(t, err)
+		value, err := v.Get("api")
+		assert.Nil(t, err)
+		assert.Equal(t, "value", value)
+	})
+}
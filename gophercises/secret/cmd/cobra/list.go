@@ -0,0 +1,62 @@
+package cobra
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var listExpired bool
+
+var listCmd = &cobra.Command{
+	Use:   "list [pattern]",
+	Short: "Lists the keys in your secret storage, optionally matching a glob pattern",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pattern := "*"
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Printf("Invalid pattern %q: %v\n", pattern, err)
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		entries, err := v.Entries()
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		var keys []string
+		now := time.Now()
+		for key, e := range entries {
+			if listExpired && !e.Expired(now) {
+				continue
+			}
+			if ok, _ := path.Match(pattern, key); !ok {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if listExpired {
+				fmt.Printf("%s (expired %s)\n", key, formatTime(*entries[key].Expires))
+				continue
+			}
+			fmt.Println(key)
+		}
+	},
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listExpired, "expired", false, "only list expired secrets")
+	RootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,41 @@
+package cobra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		setExpires, listExpired = "", false
+	}()
+
+	t.Run("it lists keys", func(t *testing.T) {
+		setCmd.Run(myCmd, []string{"list_api", "value"})
+		listCmd.Run(myCmd, nil)
+	})
+
+	t.Run("it lists only expired keys", func(t *testing.T) {
+		setExpires = "-1h"
+		setCmd.Run(myCmd, []string{"list_expired", "value"})
+		setExpires = ""
+		listExpired = true
+		listCmd.Run(myCmd, nil)
+
+		v, _ := openVault()
+		entries, err := v.Entries()
+		assert.Nil(t, err)
+		assert.True(t, entries["list_expired"].Expired(time.Now()))
+		assert.False(t, entries["list_api"].Expired(time.Now()))
+	})
+
+	t.Run("it filters keys with a glob pattern", func(t *testing.T) {
+		listExpired = false
+		listCmd.Run(myCmd, []string{"list_*"})
+		listCmd.Run(myCmd, []string{"[invalid"})
+	})
+}
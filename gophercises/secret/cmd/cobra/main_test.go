@@ -0,0 +1,46 @@
+package cobra
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gophercises/secret/cipher"
+)
+
+// TestMain points the home directory at a scratch directory so the command
+// tests never touch the real ~/.secrets or agent, never prompt, and keeps
+// key derivation cheap.
+func TestMain(m *testing.M) {
+	home, err := ioutil.TempDir("", "secret-home")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	os.Unsetenv("SECRET_KEY")
+	os.Unsetenv("SECRET_AGENT_SOCK")
+	isTerminal = func() bool { return false }
+	cipher.DefaultKDF = cipher.KDFParams{Name: "scrypt", N: 1 << 10, R: 8, P: 1}
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// captureStdout returns what f prints to stdout.
+func captureStdout(f func()) string {
+	r, w, _ := os.Pipe()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+	f()
+	w.Close()
+	return <-done
+}
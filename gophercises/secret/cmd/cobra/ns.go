@@ -0,0 +1,93 @@
+package cobra
+
+import (
+	"fmt"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var nsDeleteYes bool
+
+var nsCmd = &cobra.Command{
+	Use:   "ns",
+	Short: "Manages the namespaces of your secret storage",
+}
+
+var nsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the namespaces",
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := openStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		names, err := secret.Namespaces(s)
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	},
+}
+
+var nsCreateCmd = &cobra.Command{
+	Use:   "create <namespace>",
+	Short: "Creates a namespace sealed with its own encoding key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := openStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := secret.CheckNamespace(args[0]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		key, _, err := resolveKey(vaultID(s, args[0]))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = secret.CreateNamespace(s, args[0], key)
+		if err != nil {
+			fmt.Println("Failed to create namespace:", err)
+			return
+		}
+		fmt.Printf("Namespace %q created successfully!\n", args[0])
+	},
+}
+
+var nsDeleteCmd = &cobra.Command{
+	Use:   "delete <namespace>",
+	Short: "Deletes a namespace and every secret in it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !nsDeleteYes && !confirm(fmt.Sprintf("Delete namespace %q and all of its secrets?", args[0])) {
+			fmt.Println("Aborted.")
+			return
+		}
+		s, err := openStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = secret.DeleteNamespace(s, args[0])
+		if err != nil {
+			fmt.Println("Failed to delete namespace:", err)
+			return
+		}
+		fmt.Printf("Namespace %q deleted successfully!\n", args[0])
+	},
+}
+
+func init() {
+	nsDeleteCmd.Flags().BoolVarP(&nsDeleteYes, "yes", "y", false, "don't ask for confirmation")
+	nsCmd.AddCommand(nsListCmd, nsCreateCmd, nsDeleteCmd)
+	RootCmd.AddCommand(nsCmd)
+}
@@ -0,0 +1,77 @@
+package cobra
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNs(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		namespace, encodingKey, stdin = secret.DefaultNamespace, "", os.Stdin
+	}()
+
+	t.Run("it creates and lists namespaces", func(t *testing.T) {
+		encodingKey = "prodkey"
+		nsCreateCmd.Run(myCmd, []string{"prod"})
+		nsCreateCmd.Run(myCmd, []string{"prod"})
+		nsListCmd.Run(myCmd, nil)
+
+		names, _ := secret.Namespaces(secret.NewFileStore(secretsPath()))
+		assert.Contains(t, names, "prod")
+	})
+
+	t.Run("it reads and writes the selected namespace", func(t *testing.T) {
+		namespace, encodingKey = "prod", "prodkey"
+		setCmd.Run(myCmd, []string{"db_password", "hunter2"})
+		getCmd.Run(myCmd, []string{"db_password"})
+
+		v, err := openVault()
+		assert.Nil(t, err)
+		value, err := v.Get("db_password")
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter2", value)
+
+		namespace, encodingKey = secret.DefaultNamespace, ""
+		v, _ = openVault()
+		_, err = v.Get("db_password")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it rejects invalid namespace names", func(t *testing.T) {
+		namespace = "../etc"
+		_, err := openVault()
+		assert.NotNil(t, err)
+		getCmd.Run(myCmd, []string{"db_password"})
+		namespace = secret.DefaultNamespace
+	})
+
+	t.Run("it asks before deleting a namespace", func(t *testing.T) {
+		stdin = strings.NewReader("n\n")
+		nsDeleteCmd.Run(myCmd, []string{"prod"})
+		names, _ := secret.Namespaces(secret.NewFileStore(secretsPath()))
+		assert.Contains(t, names, "prod")
+
+		stdin = strings.NewReader("yes\n")
+		nsDeleteCmd.Run(myCmd, []string{"prod"})
+		names, _ = secret.Namespaces(secret.NewFileStore(secretsPath()))
+		assert.Contains(t, names, "prod", "no confirmation without a terminal")
+
+		isTerminal = func() bool { return true }
+		stdin = strings.NewReader("yes\n")
+		nsDeleteCmd.Run(myCmd, []string{"prod"})
+		isTerminal = func() bool { return false }
+		names, _ = secret.Namespaces(secret.NewFileStore(secretsPath()))
+		assert.NotContains(t, names, "prod")
+
+		nsDeleteYes = true
+		nsDeleteCmd.Run(myCmd, []string{"prod"})
+		nsDeleteYes = false
+	})
+}
@@ -0,0 +1,141 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"gophercises/secret/cipher"
+
+	"github.com/spf13/cobra"
+)
+
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generates an identity for vaults shared with recipients",
+	Long: `Generates an X25519 identity, writes it to --identity and prints its
+public key. Give the public key to whoever manages a shared vault so they
+can add you with "secret recipients add".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := identityPath()
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("Identity %s already exists.\n", path)
+			return
+		}
+		id, err := cipher.GenerateIdentity()
+		if err != nil {
+			fmt.Println("Failed to generate identity:", err)
+			return
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			fmt.Println("Failed to write identity:", err)
+			return
+		}
+		defer f.Close()
+		if _, err := fmt.Fprintln(f, id.String()); err != nil {
+			fmt.Println("Failed to write identity:", err)
+			return
+		}
+		fmt.Println(id.Recipient())
+	},
+}
+
+var recipientsCmd = &cobra.Command{
+	Use:   "recipients",
+	Short: "Shares the vault with other people's public keys",
+	Long: `Shares the vault with the public keys of other people, each of whom
+opens it with their own identity instead of a shared encoding key.
+
+Add your own public key first; that turns a vault sealed with an
+encoding key into a shared one. Removing a recipient rotates the data
+key, so they can't read anything written afterwards.`,
+}
+
+var recipientsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the recipients of the vault",
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		recipients, err := v.Recipients()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		var names []string
+		for name := range recipients {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s\t%s\n", name, recipients[name])
+		}
+	},
+}
+
+var recipientsAddCmd = &cobra.Command{
+	Use:   "add <name> [public key]",
+	Short: "Shares the vault with a public key, by default your own",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		recipient, err := recipientArg(args)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := v.AddRecipient(args[0], recipient); err != nil {
+			fmt.Println("Failed to add recipient:", err)
+			return
+		}
+		fmt.Printf("Recipient %q added successfully!\n", args[0])
+	},
+}
+
+var recipientsRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Revokes a recipient and rotates the data key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := v.RemoveRecipient(args[0]); err != nil {
+			fmt.Println("Failed to remove recipient:", err)
+			return
+		}
+		fmt.Printf("Recipient %q removed and data key rotated.\n", args[0])
+	},
+}
+
+// recipientArg returns the public key given to recipients add, or the
+// one of the identity.
+func recipientArg(args []string) (string, error) {
+	if len(args) == 2 {
+		return args[1], nil
+	}
+	id, err := loadIdentity()
+	if err != nil {
+		return "", err
+	}
+	if id == nil {
+		return "", errors.New("no identity, run secret keygen or pass a public key")
+	}
+	return id.Recipient(), nil
+}
+
+func init() {
+	recipientsCmd.AddCommand(recipientsListCmd, recipientsAddCmd, recipientsRemoveCmd)
+	RootCmd.AddCommand(keygenCmd, recipientsCmd)
+}
@@ -0,0 +1,62 @@
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gophercises/secret"
+	"gophercises/secret/cipher"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipients(t *testing.T) {
+	var myCmd *cobra.Command
+	dir, _ := os.MkdirTemp("", "identity")
+	defer os.RemoveAll(dir)
+	defer func() { namespace, identityFile, encodingKey = secret.DefaultNamespace, "", "" }()
+	encodingKey = "teamkey"
+	nsCreateCmd.Run(myCmd, []string{"team"})
+	namespace = "team"
+	identityFile = filepath.Join(dir, "alice")
+
+	t.Run("it generates an identity once", func(t *testing.T) {
+		keygenCmd.Run(myCmd, nil)
+		keygenCmd.Run(myCmd, nil)
+		_, err := loadIdentity()
+		assert.Nil(t, err)
+		setCmd.Run(myCmd, []string{"team_api", "value"})
+	})
+
+	t.Run("it adds yourself and others, then opens without the key", func(t *testing.T) {
+		bob, _ := cipher.GenerateIdentity()
+		recipientsAddCmd.Run(myCmd, []string{"alice"})
+		recipientsAddCmd.Run(myCmd, []string{"bob", bob.Recipient()})
+		recipientsAddCmd.Run(myCmd, []string{"bad", "nope"})
+		recipientsListCmd.Run(myCmd, nil)
+
+		encodingKey = ""
+		v, err := openVault()
+		assert.Nil(t, err)
+		recipients, err := v.Recipients()
+		assert.Nil(t, err)
+		assert.Len(t, recipients, 2)
+		value, err := v.Get("team_api")
+		assert.Nil(t, err)
+		assert.Equal(t, "value", value)
+
+		recipientsRemoveCmd.Run(myCmd, []string{"bob"})
+		recipientsRemoveCmd.Run(myCmd, []string{"bob"})
+		recipients, _ = v.Recipients()
+		assert.Len(t, recipients, 1)
+	})
+
+	t.Run("it fails without an identity", func(t *testing.T) {
+		identityFile = filepath.Join(dir, "missing")
+		recipientsAddCmd.Run(myCmd, []string{"carol"})
+		_, err := openVault()
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,90 @@
+package cobra
+
+import (
+	"fmt"
+	"strings"
+
+	"gophercises/secret/cipher"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rekeyNewKey     string
+	rekeyKDF        string
+	rekeyTime       uint32
+	rekeyMemory     uint32
+	rekeyThreads    uint8
+	rekeyN          int
+	rekeyR          int
+	rekeyP          int
+	rekeyIterations int
+)
+
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Re-encrypts your secret storage with a new key or key derivation parameters",
+	Run: func(cmd *cobra.Command, args []string) {
+		params, err := rekeyParams()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = v.Rekey(rekeyNewKey, params)
+		if err != nil {
+			fmt.Println("Failed to rekey:", err)
+			return
+		}
+		fmt.Printf("Secrets rekeyed successfully using %s!\n", params.Name)
+	},
+}
+
+// rekeyParams starts from the recommended parameters of the chosen KDF and
+// overrides every cost flag that was given.
+func rekeyParams() (cipher.KDFParams, error) {
+	params, err := cipher.DefaultParams(rekeyKDF)
+	if err != nil {
+		return params, err
+	}
+	if rekeyTime != 0 {
+		params.Time = rekeyTime
+	}
+	if rekeyMemory != 0 {
+		params.Memory = rekeyMemory
+	}
+	if rekeyThreads != 0 {
+		params.Threads = rekeyThreads
+	}
+	if rekeyN != 0 {
+		params.N = rekeyN
+	}
+	if rekeyR != 0 {
+		params.R = rekeyR
+	}
+	if rekeyP != 0 {
+		params.P = rekeyP
+	}
+	if rekeyIterations != 0 {
+		params.Iterations = rekeyIterations
+	}
+	return params, nil
+}
+
+func init() {
+	f := rekeyCmd.Flags()
+	f.StringVar(&rekeyNewKey, "new-key", "", "the new encoding key (defaults to the current key)")
+	f.StringVar(&rekeyKDF, "kdf", cipher.DefaultKDF.Name, "key derivation function: "+strings.Join(cipher.KDFs(), ", "))
+	f.Uint32Var(&rekeyTime, "time", 0, "argon2id passes over memory")
+	f.Uint32Var(&rekeyMemory, "memory", 0, "argon2id memory in KiB")
+	f.Uint8Var(&rekeyThreads, "threads", 0, "argon2id parallelism")
+	f.IntVar(&rekeyN, "scrypt-n", 0, "scrypt CPU/memory cost, a power of two")
+	f.IntVar(&rekeyR, "scrypt-r", 0, "scrypt block size")
+	f.IntVar(&rekeyP, "scrypt-p", 0, "scrypt parallelism")
+	f.IntVar(&rekeyIterations, "iterations", 0, "pbkdf2 iteration count")
+	RootCmd.AddCommand(rekeyCmd)
+}
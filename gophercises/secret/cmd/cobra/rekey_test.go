@@ -0,0 +1,48 @@
+package cobra
+
+import (
+	"os"
+	"testing"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRekey(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		rekeyNewKey, rekeyKDF, rekeyIterations = "", "argon2id", 0
+	}()
+
+	t.Run("it rekeys with a new key and kdf without losing entries", func(t *testing.T) {
+		setCmd.Run(myCmd, []string{"rekey_api", "rekeyvalue"})
+		rekeyNewKey, rekeyKDF, rekeyIterations = "newkey", "pbkdf2", 1000
+		rekeyCmd.Run(myCmd, nil)
+
+		value, err := secret.File("newkey", secretsPath()).Get("rekey_api")
+		assert.Nil(t, err)
+		assert.Equal(t, "rekeyvalue", value)
+
+		for _, suffix := range []string{"", ".audit", ".audit.head"} {
+			os.Remove(secretsPath() + suffix)
+		}
+	})
+
+	t.Run("it fails for an unknown kdf", func(t *testing.T) {
+		rekeyKDF = "md5"
+		_, err := rekeyParams()
+		assert.NotNil(t, err)
+		rekeyCmd.Run(myCmd, nil)
+	})
+
+	t.Run("it overrides the cost flags", func(t *testing.T) {
+		rekeyKDF, rekeyN = "scrypt", 1<<12
+		params, err := rekeyParams()
+		assert.Nil(t, err)
+		assert.Equal(t, 1<<12, params.N)
+		assert.Equal(t, 8, params.R)
+		rekeyN = 0
+	})
+}
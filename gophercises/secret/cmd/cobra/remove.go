@@ -1,22 +1,32 @@
 package cobra
 
 import (
+	"errors"
 	"fmt"
+
 	"gophercises/secret"
 
 	"github.com/spf13/cobra"
 )
 
 var removeCmd = &cobra.Command{
-	Use:   "remove",
+	Use:   "remove <key>",
 	Short: "Removes a secret in your secret storage",
+	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		v := secret.File(encodingKey, secretsPath())
-		key := args[0]
-		err := v.Remove(key)
+		v, err := openVault()
 		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		key := args[0]
+		err = v.Remove(key)
+		if errors.Is(err, secret.ErrNoValue) {
 			fmt.Println("value not found")
 			return
+		} else if err != nil {
+			fmt.Println(err)
+			return
 		}
 		fmt.Println("Value removed successfully!")
 	},
@@ -0,0 +1,73 @@
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderTemplate string
+	renderOutput   string
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render -t config.tmpl [-o config.yaml]",
+	Short: "Renders a config file template with secrets",
+	Long: `Renders a text/template with secrets from the vault:
+
+  password: {{ secret "db_password" }}
+  port: {{ secretOr "db_port" "5432" }}
+
+"secret" fails when the key is missing, and then nothing is written. The
+output file is created with 0600 permissions.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if renderTemplate == "" {
+			fmt.Fprintln(os.Stderr, "Give the template to render with -t.")
+			exit(1)
+			return
+		}
+		text, err := ioutil.ReadFile(renderTemplate)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+			return
+		}
+		var buf bytes.Buffer
+		err = v.Render(&buf, filepath.Base(renderTemplate), string(text))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to render:", err)
+			exit(1)
+			return
+		}
+		if renderOutput == "" || renderOutput == "-" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		err = secret.WritePrivateFile(renderOutput, buf.Bytes())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to render:", err)
+			exit(1)
+			return
+		}
+		fmt.Printf("Rendered %s to %s.\n", renderTemplate, renderOutput)
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderTemplate, "template", "t", "", "the text/template file to render")
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "file to write to, created with 0600 permissions (default stdout)")
+	RootCmd.AddCommand(renderCmd)
+}
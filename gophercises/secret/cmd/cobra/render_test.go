@@ -0,0 +1,44 @@
+package cobra
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	var myCmd *cobra.Command
+	code := 0
+	exit = func(c int) { code = c }
+	dir, _ := ioutil.TempDir("", "render")
+	defer func() {
+		exit, renderTemplate, renderOutput = os.Exit, "", ""
+		os.RemoveAll(dir)
+	}()
+	setCmd.Run(myCmd, []string{"render_password", "hunter2"})
+	renderTemplate = filepath.Join(dir, "config.tmpl")
+	renderOutput = filepath.Join(dir, "config.yaml")
+
+	t.Run("it writes the rendered file privately", func(t *testing.T) {
+		ioutil.WriteFile(renderTemplate, []byte(`password: {{ secret "render_password" }}`), 0644)
+		renderCmd.Run(myCmd, nil)
+		assert.Equal(t, 0, code)
+		data, err := ioutil.ReadFile(renderOutput)
+		assert.Nil(t, err)
+		assert.Equal(t, "password: hunter2", string(data))
+		info, _ := os.Stat(renderOutput)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("it fails on a missing key and keeps the old file", func(t *testing.T) {
+		ioutil.WriteFile(renderTemplate, []byte(`password: {{ secret "render_missing" }}`), 0644)
+		renderCmd.Run(myCmd, nil)
+		assert.Equal(t, 1, code)
+		data, _ := ioutil.ReadFile(renderOutput)
+		assert.Equal(t, "password: hunter2", string(data))
+	})
+}
@@ -0,0 +1,45 @@
+package cobra
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention [n]",
+	Short: "Shows or sets how many previous versions are kept per secret",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(args) == 0 {
+			n, err := v.Retention()
+			if err != nil {
+				fmt.Println("Something went wrong:", err)
+				return
+			}
+			fmt.Printf("Keeping %d previous versions per secret.\n", n)
+			return
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Failed to parse the argument:", args[0])
+			return
+		}
+		err = v.SetRetention(n)
+		if err != nil {
+			fmt.Println("Failed to set retention:", err)
+			return
+		}
+		fmt.Printf("Keeping %d previous versions per secret.\n", n)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(retentionCmd)
+}
@@ -0,0 +1,33 @@
+package cobra
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var rollbackVersion int
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <key> --version n",
+	Short: "Restores a previous version of a secret",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = v.Rollback(args[0], rollbackVersion)
+		if err != nil {
+			fmt.Println("Failed to roll back:", err)
+			return
+		}
+		fmt.Printf("Rolled %s back to version %d.\n", args[0], rollbackVersion)
+	},
+}
+
+func init() {
+	rollbackCmd.Flags().IntVar(&rollbackVersion, "version", 0, "the version to restore, as shown by history")
+	RootCmd.AddCommand(rollbackCmd)
+}
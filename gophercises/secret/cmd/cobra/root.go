@@ -1,7 +1,17 @@
 package cobra
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"gophercises/secret"
+	"gophercises/secret/agent"
+	"gophercises/secret/cipher"
 
 	"github.com/spf13/cobra"
 
@@ -11,15 +21,128 @@ import (
 var RootCmd = &cobra.Command{
 	Use:   "secret",
 	Short: "Secret is an API key and other secrets manager",
+	Long: `Secret is an API key and other secrets manager.
+
+The encoding key is taken from the first of: --key, --key-file, --key-fd,
+the SECRET_KEY environment variable, a running "secret agent", or a
+prompt when stdin is a terminal.
+
+A vault shared with recipients (see "secret recipients") is opened with
+the identity in --identity instead.`,
 }
 
+// stdin is where confirmations are read from.
+var stdin io.Reader = os.Stdin
+
 var encodingKey string
+var namespace string
+var backend string
+var storePath string
+var backup bool
+var lockTimeout time.Duration
+
+// memoryStore is shared by every command run in this process with
+// --backend memory.
+var memoryStore = secret.NewMemoryStore()
 
 func init() {
-	RootCmd.PersistentFlags().StringVarP(&encodingKey, "key", "k", "", "the key to use when encoding and decoding secrets")
+	RootCmd.PersistentFlags().StringVarP(&encodingKey, "key", "k", "", "the key to use when encoding and decoding secrets (visible in shell history and ps; prefer the other sources)")
+	RootCmd.PersistentFlags().StringVar(&keyFile, "key-file", "", "read the encoding key from this file")
+	RootCmd.PersistentFlags().IntVar(&keyFD, "key-fd", -1, "read the encoding key from this file descriptor")
+	RootCmd.PersistentFlags().StringVar(&identityFile, "identity", "", "the identity opening vaults shared with recipients (default ~/.secret-identity)")
+	RootCmd.PersistentFlags().StringVar(&namespace, "ns", secret.DefaultNamespace, "the namespace to use")
+	RootCmd.PersistentFlags().StringVar(&backend, "backend", "file", "where secrets are kept: file, dir, bolt or memory")
+	RootCmd.PersistentFlags().StringVar(&storePath, "store", "", "the file or directory of the backend (default ~/.secrets, ~/.secrets.store or ~/tasks.db)")
+	RootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", secret.DefaultLockTimeout, "how long to wait for other secret commands to release the secrets file")
+	RootCmd.PersistentFlags().BoolVar(&backup, "backup", false, "keep the previous generation of the secrets file as .bak")
+}
+
+// openVault returns the vault selected by the persistent flags.
+func openVault() (*secret.Vault, error) {
+	s, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := secret.CheckNamespace(namespace); err != nil {
+		return nil, err
+	}
+	id, err := loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	// A shared vault needs no encoding key, so don't prompt for one.
+	key, prompted := "", false
+	if data, _, err := s.Read(namespace); err != nil || id == nil || !cipher.IsShared(data) {
+		if key, prompted, err = resolveKey(vaultID(s, namespace)); err != nil {
+			return nil, err
+		}
+	}
+	v := secret.New(key, s, namespace)
+	v.SetIdentity(id)
+	v.SetBackup(backup)
+	v.SetLockTimeout(lockTimeout)
+	if prompted {
+		// A prompted key is handed to the agent, if one is running, so it
+		// isn't asked for again, but only once it has opened the vault so
+		// a typo isn't served until it expires.
+		if err := v.Load(); err != nil {
+			return nil, err
+		}
+		agent.Put(agentSocket(), vaultID(s, namespace), key, 0)
+	}
+	return v, nil
 }
 
+// openStore returns the store selected by --backend and --store.
+func openStore() (secret.Store, error) {
+	home, _ := homedir.Dir()
+	path := func(def string) string {
+		if storePath != "" {
+			return storePath
+		}
+		return filepath.Join(home, def)
+	}
+	switch backend {
+	case "file":
+		return secret.NewFileStore(secretsPath()), nil
+	case "dir":
+		return secret.NewDirStore(path(".secrets.store")), nil
+	case "bolt":
+		return secret.NewBoltStore(path("tasks.db"), secret.DefaultBoltBucket), nil
+	case "memory":
+		return memoryStore, nil
+	}
+	return nil, fmt.Errorf("unknown backend %q, use file, dir, bolt or memory", backend)
+}
+
+// vaultID names namespace ns of s for key prompts and the agent.
+func vaultID(s secret.Store, ns string) string {
+	if l, ok := s.(secret.LocalStore); ok {
+		return l.Path(ns)
+	}
+	return backend + ":" + ns
+}
+
+// secretsPath returns the file holding the default namespace of the file
+// backend.
 func secretsPath() string {
+	if storePath != "" && backend == "file" {
+		return storePath
+	}
 	home, _ := homedir.Dir()
 	return filepath.Join(home, ".secrets")
 }
+
+// confirm asks a yes/no question on stderr, so the prompt doesn't end up
+// in output redirected from stdout, and reports whether the answer was
+// yes. Without a terminal to answer on it doesn't ask and says no.
+func confirm(question string) bool {
+	if !isTerminal() {
+		fmt.Fprintf(os.Stderr, "%s Not asking without a terminal; use --yes.\n", question)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
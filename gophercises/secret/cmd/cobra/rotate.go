@@ -0,0 +1,60 @@
+package cobra
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var rotateDue bool
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate [key...] [--due]",
+	Short: "Regenerates secrets from their rotation policy",
+	Long: `Regenerates the given keys from the policy they were generated with.
+With --due every key whose maximum age has elapsed is regenerated instead.
+The previous values are kept in the history of each key.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if rotateDue == (len(args) > 0) {
+			fmt.Println("Give either keys to rotate or --due.")
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		rotated := args
+		if rotateDue {
+			rotated, err = v.RotateDue()
+			if err != nil {
+				fmt.Println("Failed to rotate:", err)
+				return
+			}
+		} else {
+			for _, key := range args {
+				if err := v.Rotate(key); err != nil {
+					fmt.Printf("Failed to rotate %s: %v\n", key, err)
+					return
+				}
+			}
+		}
+		if len(rotated) == 0 {
+			fmt.Println("No secrets are due for rotation.")
+			return
+		}
+		entries, err := v.Entries()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		for _, key := range rotated {
+			fmt.Printf("Rotated %s to version %d.\n", key, entries[key].Version)
+		}
+	},
+}
+
+func init() {
+	rotateCmd.Flags().BoolVar(&rotateDue, "due", false, "rotate every secret whose maximum age has elapsed")
+	RootCmd.AddCommand(rotateCmd)
+}
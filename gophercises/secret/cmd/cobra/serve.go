@@ -0,0 +1,86 @@
+package cobra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gophercises/secret/api"
+
+	"github.com/spf13/cobra"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+var serveListen string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves the secrets over a local HTTP API",
+	Long: `Serves the secrets of the selected namespace over a local HTTP/JSON API.
+
+Clients authenticate with a bearer token from "secret token create". The
+API listens on a Unix socket, or on a loopback host:port. Requests are
+recorded in the audit log of the vault under the name of their token.
+
+  GET    /v1/secrets        list keys
+  GET    /v1/secrets/{key}  get a value
+  PUT    /v1/secrets/{key}  set a value, body {"value": "..."}
+  DELETE /v1/secrets/{key}  delete a value`,
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		// Fail now rather than on the first request if the key is wrong.
+		if _, err := v.Tokens(); err != nil {
+			fmt.Println("Failed to unlock:", err)
+			return
+		}
+		addr := serveListen
+		if addr == "" {
+			addr = apiSocket()
+		}
+		l, err := api.Listen(addr)
+		if err != nil {
+			fmt.Println("Failed to listen:", err)
+			return
+		}
+		if strings.Contains(addr, "/") {
+			defer os.Remove(strings.TrimPrefix(addr, "unix:"))
+		}
+
+		server := &http.Server{Handler: api.NewHandler(v), ReadHeaderTimeout: 10 * time.Second}
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			<-signals
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(ctx)
+		}()
+		fmt.Printf("Serving secrets on %s.\n", addr)
+		if err := server.Serve(l); err != http.ErrServerClosed {
+			fmt.Println("Server stopped:", err)
+		}
+	},
+}
+
+// apiSocket returns the default Unix socket of secret serve.
+func apiSocket() string {
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".secret-api.sock")
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "", "unix socket path or loopback host:port to listen on (default ~/.secret-api.sock)")
+	RootCmd.AddCommand(serveCmd)
+}
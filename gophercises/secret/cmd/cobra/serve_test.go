@@ -0,0 +1,19 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { serveListen = "" }()
+
+	t.Run("it refuses to listen beyond loopback", func(t *testing.T) {
+		serveListen = "0.0.0.0:0"
+		out := captureStdout(func() { serveCmd.Run(myCmd, nil) })
+		assert.Contains(t, out, "Failed to listen")
+	})
+}
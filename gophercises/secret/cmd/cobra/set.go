@@ -2,26 +2,85 @@ package cobra
 
 import (
 	"fmt"
+	"time"
 
 	"gophercises/secret"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	setDescription string
+	setTags        []string
+	setExpires     string
+)
+
 var setCmd = &cobra.Command{
-	Use:   "set",
+	Use:   "set <key> <value>",
 	Short: "Sets a secret in your secret storage",
+	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		v := secret.File(encodingKey, secretsPath())
+		opts, err := setOptions(time.Now())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 		key, value := args[0], args[1]
-		err := v.Set(key, value)
+		err = v.Set(key, value, opts...)
 		if err != nil {
-			panic(err)
+			fmt.Println("Failed to set:", err)
+			return
 		}
 		fmt.Println("Value set successfully!")
 	},
 }
 
+// setOptions turns the metadata flags that were given into options for
+// secret.Vault.Set, leaving the other metadata of the key as it was.
+func setOptions(now time.Time) ([]secret.SetOption, error) {
+	var opts []secret.SetOption
+	if setDescription != "" {
+		opts = append(opts, secret.WithDescription(setDescription))
+	}
+	if len(setTags) > 0 {
+		opts = append(opts, secret.WithTags(setTags...))
+	}
+	if setExpires != "" {
+		expires, err := parseExpiry(setExpires, now)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, secret.WithExpiry(expires))
+	}
+	return opts, nil
+}
+
+// parseExpiry accepts a duration from now such as "720h", a date such as
+// "2025-12-31", an RFC 3339 time, or "never" which gives the zero time.
+func parseExpiry(s string, now time.Time) (time.Time, error) {
+	if s == "never" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry %q, use a duration, a date (2006-01-02), an RFC 3339 time or never", s)
+}
+
 func init() {
+	setCmd.Flags().StringVarP(&setDescription, "description", "d", "", "a description of the secret")
+	setCmd.Flags().StringSliceVarP(&setTags, "tag", "t", nil, "tags for the secret, replacing any existing tags")
+	setCmd.Flags().StringVarP(&setExpires, "expires", "e", "", "when the secret expires: a duration, a date, an RFC 3339 time or never")
 	RootCmd.AddCommand(setCmd)
 }
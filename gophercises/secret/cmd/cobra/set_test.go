@@ -2,8 +2,10 @@ package cobra
 
 import (
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSet(t *testing.T) {
@@ -15,3 +17,59 @@ func TestSet(t *testing.T) {
 	})
 
 }
+
+func TestSetMetadata(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		setDescription, setTags, setExpires = "", nil, ""
+	}()
+
+	t.Run("it stores description, tags and expiry", func(t *testing.T) {
+		setDescription, setTags, setExpires = "twitter key", []string{"social", "prod"}, "2030-01-02"
+		setCmd.Run(myCmd, []string{"meta_api", "value"})
+
+		v, _ := openVault()
+		e, err := v.GetEntry("meta_api")
+		assert.Nil(t, err)
+		assert.Equal(t, "twitter key", e.Description)
+		assert.Equal(t, []string{"social", "prod"}, e.Tags)
+		assert.Equal(t, 2030, e.Expires.Year())
+	})
+
+	t.Run("it keeps metadata when only the value changes", func(t *testing.T) {
+		setDescription, setTags, setExpires = "", nil, ""
+		setCmd.Run(myCmd, []string{"meta_api", "newvalue"})
+
+		v, _ := openVault()
+		e, _ := v.GetEntry("meta_api")
+		assert.Equal(t, "newvalue", e.Value)
+		assert.Equal(t, "twitter key", e.Description)
+	})
+
+	t.Run("it rejects an invalid expiry", func(t *testing.T) {
+		setExpires = "someday"
+		setCmd.Run(myCmd, []string{"meta_api", "other"})
+		v, _ := openVault()
+		e, _ := v.GetEntry("meta_api")
+		assert.Equal(t, "newvalue", e.Value)
+	})
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got, err := parseExpiry("24h", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(24*time.Hour), got)
+
+	got, err = parseExpiry("2020-01-01T12:00:00Z", now)
+	assert.Nil(t, err)
+	assert.Equal(t, 12, got.Hour())
+
+	got, err = parseExpiry("never", now)
+	assert.Nil(t, err)
+	assert.True(t, got.IsZero())
+
+	_, err = parseExpiry("soon", now)
+	assert.NotNil(t, err)
+}
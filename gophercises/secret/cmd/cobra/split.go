@@ -0,0 +1,176 @@
+package cobra
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gophercises/secret"
+	"gophercises/secret/agent"
+	"gophercises/secret/cipher"
+	"gophercises/secret/shamir"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	splitShares    int
+	splitThreshold int
+	unsealPrint    bool
+)
+
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Rekeys the vault with a random master key split into shares",
+	Long: `Rekeys the vault with a new random master key and prints it split into
+shares with Shamir's secret sharing, so no single person holds the key.
+Hand each share to a different person; any --threshold of them can
+unlock the vault with "secret unseal". The master key itself is never
+shown or stored.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		shares, err := splitVault(v, splitShares, splitThreshold)
+		if err != nil {
+			fmt.Println("Failed to split:", err)
+			return
+		}
+		fmt.Printf("Vault rekeyed, any %d of these %d shares unlock it:\n", splitThreshold, splitShares)
+		for _, share := range shares {
+			fmt.Println(share)
+		}
+	},
+}
+
+var unsealCmd = &cobra.Command{
+	Use:   "unseal [share...]",
+	Short: "Reconstructs the master key from shares and hands it to the agent",
+	Long: `Reconstructs the master key of a vault split with "secret split" from
+enough shares, given as arguments or one per line on stdin, and hands it
+to the running agent so the following commands can use the vault. With
+--print the key is printed instead, e.g. for --key-fd.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shares := args
+		if len(shares) == 0 {
+			var err error
+			if shares, err = readShares(); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+		key, err := combineShares(shares)
+		if err != nil {
+			fmt.Println("Failed to unseal:", err)
+			return
+		}
+		s, err := openStore()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := secret.CheckNamespace(namespace); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := secret.New(key, s, namespace).Load(); err != nil {
+			fmt.Println("Failed to unseal, not enough or wrong shares:", err)
+			return
+		}
+		if unsealPrint {
+			fmt.Println(key)
+			return
+		}
+		if err := agent.Put(agentSocket(), vaultID(s, namespace), key, agentTTL); err != nil {
+			fmt.Println("Failed to reach agent, start it with secret agent start or use --print:", err)
+			return
+		}
+		fmt.Println("Vault unsealed.")
+	},
+}
+
+// splitVault rekeys v with a random master key and returns n shares of
+// it, any threshold of which recover it.
+func splitVault(v *secret.Vault, n, threshold int) ([]string, error) {
+	if recipients, err := v.Recipients(); err != nil {
+		return nil, err
+	} else if len(recipients) > 0 {
+		return nil, errors.New("the vault is shared with recipients, it has no encoding key to split")
+	}
+	master := make([]byte, 32)
+	if _, err := rand.Read(master); err != nil {
+		return nil, err
+	}
+	key := hex.EncodeToString(master)
+	parts, err := shamir.Split([]byte(key), n, threshold)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Rekey(key, cipher.DefaultKDF); err != nil {
+		return nil, err
+	}
+	shares := make([]string, len(parts))
+	for i, p := range parts {
+		shares[i] = hex.EncodeToString(p)
+	}
+	return shares, nil
+}
+
+func combineShares(shares []string) (string, error) {
+	var parts [][]byte
+	for _, share := range shares {
+		p, err := hex.DecodeString(strings.TrimSpace(share))
+		if err != nil {
+			return "", fmt.Errorf("invalid share %q", share)
+		}
+		parts = append(parts, p)
+	}
+	key, err := shamir.Combine(parts)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// readShares reads shares one per line, prompting for each without echo
+// when stdin is a terminal, until an empty line or the end of input.
+func readShares() ([]string, error) {
+	var shares []string
+	if isTerminal() {
+		for {
+			fmt.Fprintf(os.Stderr, "Share %d (empty to finish): ", len(shares)+1)
+			line, err := readPassword()
+			fmt.Fprintln(os.Stderr)
+			if err != nil {
+				return nil, err
+			}
+			if len(line) == 0 {
+				return shares, nil
+			}
+			shares = append(shares, string(line))
+		}
+	}
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			break
+		}
+		shares = append(shares, line)
+	}
+	return shares, scanner.Err()
+}
+
+func init() {
+	splitCmd.Flags().IntVar(&splitShares, "shares", 5, "how many shares to create")
+	splitCmd.Flags().IntVar(&splitThreshold, "threshold", 3, "how many shares unlock the vault")
+	unsealCmd.Flags().BoolVar(&unsealPrint, "print", false, "print the key instead of handing it to the agent")
+	unsealCmd.Flags().DurationVar(&agentTTL, "ttl", agent.DefaultTTL, "how long the agent keeps the key")
+	RootCmd.AddCommand(splitCmd, unsealCmd)
+}
@@ -0,0 +1,63 @@
+package cobra
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		namespace, encodingKey, unsealPrint, stdin = secret.DefaultNamespace, "", false, os.Stdin
+	}()
+	encodingKey = "splitkey"
+	nsCreateCmd.Run(myCmd, []string{"split"})
+	namespace = "split"
+	setCmd.Run(myCmd, []string{"split_api", "value"})
+
+	v, _ := openVault()
+	shares, err := splitVault(v, 5, 3)
+	assert.Nil(t, err)
+	assert.Len(t, shares, 5)
+
+	t.Run("the old key no longer opens the vault", func(t *testing.T) {
+		_, err := v.Get("split_api")
+		assert.Nil(t, err)
+		old, _ := openVault()
+		_, err = old.Get("split_api")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("enough shares recover the key", func(t *testing.T) {
+		key, err := combineShares([]string{shares[4], shares[0], shares[2]})
+		assert.Nil(t, err)
+		encodingKey = key
+		v, _ := openVault()
+		value, err := v.Get("split_api")
+		assert.Nil(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("unseal checks the shares", func(t *testing.T) {
+		unsealPrint = true
+		unsealCmd.Run(myCmd, shares[:3])
+		unsealCmd.Run(myCmd, shares[:2])
+		unsealCmd.Run(myCmd, []string{"zz", "yy"})
+		stdin = strings.NewReader(strings.Join(shares[1:4], "\n") + "\n")
+		got, err := readShares()
+		assert.Nil(t, err)
+		assert.Equal(t, shares[1:4], got)
+	})
+
+	t.Run("it rejects bad parameters", func(t *testing.T) {
+		splitShares, splitThreshold = 2, 3
+		splitCmd.Run(myCmd, nil)
+		splitShares, splitThreshold = 5, 3
+	})
+}
@@ -0,0 +1,87 @@
+package cobra
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+)
+
+var tokenScopes []string
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manages the client tokens of secret serve",
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Creates a client token and prints it once",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		token, err := v.CreateToken(args[0], tokenScopes...)
+		if err != nil {
+			fmt.Println("Failed to create token:", err)
+			return
+		}
+		fmt.Println(token)
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the client tokens",
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		tokens, err := v.Tokens()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		var names []string
+		for name := range tokens {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			t := tokens[name]
+			fmt.Printf("%s\t%s\tcreated %s\n", name, strings.Join(t.Scopes, ","), formatTime(t.Created))
+		}
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revokes a client token",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := v.RevokeToken(args[0]); err != nil {
+			fmt.Println("Failed to revoke token:", err)
+			return
+		}
+		fmt.Println("Token revoked.")
+	},
+}
+
+func init() {
+	tokenCreateCmd.Flags().StringSliceVar(&tokenScopes, "scope", []string{secret.ScopeRead}, "scopes to grant, read and/or write")
+	tokenCmd.AddCommand(tokenCreateCmd, tokenListCmd, tokenRevokeCmd)
+	RootCmd.AddCommand(tokenCmd)
+}
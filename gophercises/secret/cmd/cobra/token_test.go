@@ -0,0 +1,36 @@
+package cobra
+
+import (
+	"testing"
+
+	"gophercises/secret"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { tokenScopes = []string{secret.ScopeRead} }()
+
+	t.Run("it creates, lists and revokes tokens", func(t *testing.T) {
+		tokenScopes = []string{secret.ScopeRead, secret.ScopeWrite}
+		tokenCreateCmd.Run(myCmd, []string{"cli_token"})
+		tokenListCmd.Run(myCmd, nil)
+
+		v, _ := openVault()
+		tokens, err := v.Tokens()
+		assert.Nil(t, err)
+		assert.True(t, tokens["cli_token"].Allows(secret.ScopeWrite))
+
+		tokenRevokeCmd.Run(myCmd, []string{"cli_token"})
+		tokens, _ = v.Tokens()
+		assert.NotContains(t, tokens, "cli_token")
+	})
+
+	t.Run("it reports unknown tokens and scopes", func(t *testing.T) {
+		tokenRevokeCmd.Run(myCmd, []string{"missing"})
+		tokenScopes = []string{"admin"}
+		tokenCreateCmd.Run(myCmd, []string{"bad_scope"})
+	})
+}
@@ -0,0 +1,122 @@
+package secret
+
+import (
+	"encoding/json"
+	"time"
+)
+
+var now = time.Now
+
+// DefaultRetention is the number of previous versions kept per key when
+// the vault doesn't set its own retention.
+const DefaultRetention = 5
+
+// Entry is a stored secret along with its metadata. Secrets written by
+// older versions are plain strings and load with only Value set.
+type Entry struct {
+	Value       string     `json:"value"`
+	Version     int        `json:"version"`
+	Created     time.Time  `json:"created"`
+	Updated     time.Time  `json:"updated"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Expires     *time.Time `json:"expires,omitempty"`
+	Rotation    *Policy    `json:"rotation,omitempty"`
+	History     []Version  `json:"history,omitempty"`
+}
+
+// Version is a previous value of an entry, newest first in Entry.History.
+type Version struct {
+	Version int       `json:"version"`
+	Value   string    `json:"value"`
+	Updated time.Time `json:"updated"`
+}
+
+// Expired reports whether the entry has an expiry that is not after t.
+func (e Entry) Expired(t time.Time) bool {
+	return e.Expires != nil && !e.Expires.After(t)
+}
+
+// UnmarshalJSON accepts both an entry object and a bare string value.
+// Entries written before versioning count as version 1.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*e = Entry{Value: value, Version: 1}
+		return nil
+	}
+	type entry Entry
+	if err := json.Unmarshal(data, (*entry)(e)); err != nil {
+		return err
+	}
+	if e.Version == 0 {
+		e.Version = 1
+	}
+	return nil
+}
+
+// update makes value the current version of the entry, keeping at most
+// retention previous versions. Setting the current value again is a no-op.
+func (e *Entry) update(value string, t time.Time, retention int) {
+	if e.Version == 0 {
+		e.Version = 1
+		e.Value = value
+		e.Updated = t
+		return
+	}
+	if e.Value == value {
+		return
+	}
+	e.History = append([]Version{{Version: e.Version, Value: e.Value, Updated: e.Updated}}, e.History...)
+	e.trim(retention)
+	e.Version++
+	e.Value = value
+	e.Updated = t
+}
+
+func (e *Entry) trim(retention int) {
+	if len(e.History) > retention {
+		e.History = e.History[:retention]
+	}
+	if len(e.History) == 0 {
+		e.History = nil
+	}
+}
+
+// SetOption changes the metadata of an entry written by Set.
+type SetOption func(*Entry)
+
+// WithDescription sets the description of the entry.
+func WithDescription(description string) SetOption {
+	return func(e *Entry) {
+		e.Description = description
+	}
+}
+
+// WithTags replaces the tags of the entry.
+func WithTags(tags ...string) SetOption {
+	return func(e *Entry) {
+		e.Tags = tags
+	}
+}
+
+// WithExpiry makes the entry expire at t. A zero t removes the expiry.
+func WithExpiry(t time.Time) SetOption {
+	return func(e *Entry) {
+		if t.IsZero() {
+			e.Expires = nil
+			return
+		}
+		e.Expires = &t
+	}
+}
+
+// document is the plaintext layout of a vault file. Older vaults are a
+// flat JSON object of key to value instead.
+type document struct {
+	Retention  *int              `json:"retention,omitempty"`
+	Tokens     map[string]*Token `json:"tokens,omitempty"`
+	Recipients map[string]string `json:"recipients,omitempty"`
+	Audit      *auditHead        `json:"audit,omitempty"`
+	Secrets    map[string]*Entry `json:"secrets"`
+}
@@ -0,0 +1,89 @@
+package secret
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntry(t *testing.T) {
+	t.Run("it decodes a bare string value", func(t *testing.T) {
+		var e Entry
+		assert.Nil(t, json.Unmarshal([]byte(`"plain"`), &e))
+		assert.Equal(t, Entry{Value: "plain", Version: 1}, e)
+	})
+
+	t.Run("it decodes an entry object", func(t *testing.T) {
+		var e Entry
+		assert.Nil(t, json.Unmarshal([]byte(`{"value":"v","description":"d","tags":["a"]}`), &e))
+		assert.Equal(t, "v", e.Value)
+		assert.Equal(t, "d", e.Description)
+		assert.Equal(t, []string{"a"}, e.Tags)
+	})
+
+	t.Run("it reports expiry", func(t *testing.T) {
+		t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		e := Entry{}
+		assert.False(t, e.Expired(t0))
+		WithExpiry(t0)(&e)
+		assert.True(t, e.Expired(t0))
+		assert.False(t, e.Expired(t0.Add(-time.Second)))
+		WithExpiry(time.Time{})(&e)
+		assert.Nil(t, e.Expires)
+	})
+}
+
+func TestSetMetadata(t *testing.T) {
+	defer func() { now = time.Now }()
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	t.Run("it records timestamps and keeps metadata on update", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		now = func() time.Time { return created }
+		assert.Nil(t, v.Set("test_key", "one", WithDescription("api key"), WithTags("prod")))
+		now = func() time.Time { return updated }
+		assert.Nil(t, v.Set("test_key", "two"))
+
+		e, err := v.GetEntry("test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "two", e.Value)
+		assert.Equal(t, "api key", e.Description)
+		assert.Equal(t, []string{"prod"}, e.Tags)
+		assert.True(t, created.Equal(e.Created))
+		assert.True(t, updated.Equal(e.Updated))
+	})
+
+	t.Run("it loads old vaults with plain string values unchanged", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		writeLegacyVault(t, v, `{"secrets":"a legacy key","other":"value"}`)
+
+		entries, err := v.Entries()
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]Entry{
+			"secrets": {Value: "a legacy key", Version: 1},
+			"other":   {Value: "value", Version: 1},
+		}, entries)
+
+		assert.Nil(t, v.Set("new", "value"))
+		value, err := v.Get("secrets")
+		assert.Nil(t, err)
+		assert.Equal(t, "a legacy key", value)
+	})
+
+	t.Run("it reads both the flat and the document layout", func(t *testing.T) {
+		v := InitFile()
+		assert.Nil(t, v.readKeyValues(strings.NewReader(`{"a":"b"}`)))
+		assert.Equal(t, "b", v.keyValues["a"].Value)
+		assert.Nil(t, v.readKeyValues(strings.NewReader(`{"secrets":{"a":{"value":"c"}}}`)))
+		assert.Equal(t, "c", v.keyValues["a"].Value)
+		assert.Nil(t, v.readKeyValues(strings.NewReader("")))
+		assert.Empty(t, v.keyValues)
+	})
+}
@@ -0,0 +1,120 @@
+package secret
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"gophercises/secret/cipher"
+)
+
+// ErrNeedFileKey is returned by EncryptFile for a vault shared with
+// recipients, which has no encoding key to derive a file key from.
+var ErrNeedFileKey = errors.New("secret: the vault is shared with recipients, encrypt with a file key instead")
+
+// EncryptFile encrypts r to w in authenticated chunks with
+// cipher.SealStream, so files of any size are streamed. The key is
+// derived from the encoding key or, with fileKey set, is the random key
+// kept in the vault as the secret named fileKey, created on first use.
+func (v *Vault) EncryptFile(w io.Writer, r io.Reader, fileKey string) error {
+	var sw io.WriteCloser
+	if fileKey != "" {
+		key, err := v.fileKey(fileKey)
+		if err != nil {
+			return err
+		}
+		if sw, err = cipher.SealStream(w, key, cipher.StreamHeader{KeyName: fileKey}); err != nil {
+			return err
+		}
+	} else {
+		params, err := v.streamKDF()
+		if err != nil {
+			return err
+		}
+		if sw, err = cipher.SealStreamWith(w, v.encodingKey, params); err != nil {
+			return err
+		}
+	}
+	if _, err := io.Copy(sw, r); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// DecryptFile decrypts r, written by EncryptFile, to w. The output is
+// only complete and authentic when it returns nil; a truncated or
+// modified file fails part way through.
+func (v *Vault) DecryptFile(w io.Writer, r io.Reader) error {
+	sr, err := cipher.OpenStream(r, func(h cipher.StreamHeader) ([]byte, error) {
+		if h.KeyName == "" {
+			return cipher.PassphraseKey(v.encodingKey)(h)
+		}
+		e, err := v.GetEntry(h.KeyName)
+		if err != nil {
+			return nil, fmt.Errorf("secret: file key %q: %w", h.KeyName, err)
+		}
+		return decodeFileKey(h.KeyName, e.Value)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, sr)
+	return err
+}
+
+// streamKDF checks the encoding key against the vault and returns the
+// key derivation parameters files are encrypted with.
+func (v *Vault) streamKDF() (cipher.KDFParams, error) {
+	unlock, err := v.lock(false)
+	if err != nil {
+		return cipher.KDFParams{}, err
+	}
+	defer unlock()
+	if err := v.Load(); err != nil {
+		return cipher.KDFParams{}, err
+	}
+	if v.shared() {
+		return cipher.KDFParams{}, ErrNeedFileKey
+	}
+	if v.kdf.Name == "" {
+		return cipher.DefaultKDF, nil
+	}
+	return v.kdf, nil
+}
+
+// fileKey returns the file key kept as the secret name, generating and
+// storing it first if there is none.
+func (v *Vault) fileKey(name string) ([]byte, error) {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := v.Load(); err != nil {
+		return nil, err
+	}
+	if e, ok := v.keyValues[name]; ok {
+		return decodeFileKey(name, e.Value)
+	}
+	key, err := cipher.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	t := now()
+	e := &Entry{Created: t, Description: "file encryption key"}
+	e.update(hex.EncodeToString(key), t, v.retentionCount())
+	v.keyValues[name] = e
+	if err := v.record("set", name, v.Save()); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func decodeFileKey(name, value string) ([]byte, error) {
+	key, err := hex.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("secret: %q is not a file key", name)
+	}
+	return key, nil
+}
@@ -0,0 +1,57 @@
+package secret
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gophercises/secret/cipher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptFile(t *testing.T) {
+	v := InitFile()
+	defer removeVault(v)
+	plaintext := strings.Repeat("a large file ", 20000)
+
+	t.Run("it round trips with the encoding key", func(t *testing.T) {
+		var enc, dec bytes.Buffer
+		assert.Nil(t, v.EncryptFile(&enc, strings.NewReader(plaintext), ""))
+		assert.True(t, cipher.IsStream(enc.Bytes()))
+		assert.Nil(t, v.DecryptFile(&dec, bytes.NewReader(enc.Bytes())))
+		assert.Equal(t, plaintext, dec.String())
+
+		other := InitFile()
+		other.filepath, other.encodingKey = v.filepath, "wrong"
+		assert.Equal(t, cipher.ErrAuthentication, other.DecryptFile(&dec, bytes.NewReader(enc.Bytes())))
+	})
+
+	t.Run("it creates and reuses a file key", func(t *testing.T) {
+		var a, b, dec bytes.Buffer
+		assert.Nil(t, v.EncryptFile(&a, strings.NewReader("one"), "backup_key"))
+		key, err := v.Get("backup_key")
+		assert.Nil(t, err)
+		assert.Len(t, key, 64)
+		assert.Nil(t, v.EncryptFile(&b, strings.NewReader("two"), "backup_key"))
+		again, _ := v.Get("backup_key")
+		assert.Equal(t, key, again)
+
+		assert.Nil(t, v.DecryptFile(&dec, bytes.NewReader(a.Bytes())))
+		assert.Equal(t, "one", dec.String())
+	})
+
+	t.Run("it refuses a secret that isn't a file key", func(t *testing.T) {
+		assert.Nil(t, v.Set("plain", "value"))
+		err := v.EncryptFile(&bytes.Buffer{}, strings.NewReader("x"), "plain")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it needs the file key to decrypt", func(t *testing.T) {
+		var enc bytes.Buffer
+		assert.Nil(t, v.EncryptFile(&enc, strings.NewReader("x"), "gone_key"))
+		assert.Nil(t, v.Remove("gone_key"))
+		err := v.DecryptFile(&bytes.Buffer{}, bytes.NewReader(enc.Bytes()))
+		assert.ErrorIs(t, err, ErrNoValue)
+	})
+}
@@ -0,0 +1,132 @@
+package secret
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Formats lists the plaintext formats understood by EncodeValues and
+// DecodeValues.
+var Formats = []string{"dotenv", "json", "yaml"}
+
+var dotenvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// FormatFromPath guesses the format of a file from its extension, falling
+// back to dotenv.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return "dotenv"
+	}
+}
+
+// EncodeValues writes values to w in the given plaintext format.
+func EncodeValues(w io.Writer, format string, values map[string]string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(values)
+	case "yaml":
+		data, err := yaml.Marshal(values)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "dotenv":
+		return encodeDotenv(w, values)
+	default:
+		return fmt.Errorf("secret: unknown format %q", format)
+	}
+}
+
+// DecodeValues reads key value pairs in the given plaintext format.
+func DecodeValues(r io.Reader, format string) (map[string]string, error) {
+	values := make(map[string]string)
+	switch format {
+	case "json":
+		if err := json.NewDecoder(r).Decode(&values); err != nil {
+			return nil, err
+		}
+	case "yaml":
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	case "dotenv":
+		return decodeDotenv(r)
+	default:
+		return nil, fmt.Errorf("secret: unknown format %q", format)
+	}
+	return values, nil
+}
+
+func encodeDotenv(w io.Writer, values map[string]string) error {
+	var keys []string
+	for key := range values {
+		if !dotenvKey.MatchString(key) {
+			return fmt.Errorf("secret: key %q can't be written as dotenv", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, strconv.Quote(values[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeDotenv reads KEY=value lines. Blank lines, comments and an
+// "export " prefix are ignored; values may be double quoted with Go
+// escapes, single quoted literally, or bare.
+func decodeDotenv(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("secret: dotenv line %d: missing '='", n)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if !dotenvKey.MatchString(key) {
+			return nil, fmt.Errorf("secret: dotenv line %d: invalid key %q", n, key)
+		}
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("secret: dotenv line %d: %v", n, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,62 @@
+package secret
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormats(t *testing.T) {
+	values := map[string]string{
+		"api_key":  "abc123",
+		"password": "with \"quotes\" and\nnewline",
+		"empty":    "",
+	}
+
+	for _, format := range Formats {
+		t.Run("it round trips "+format, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Nil(t, EncodeValues(&buf, format, values))
+			got, err := DecodeValues(&buf, format)
+			assert.Nil(t, err)
+			assert.Equal(t, values, got)
+		})
+	}
+
+	t.Run("it reads hand written dotenv files", func(t *testing.T) {
+		in := "# comment\n\nexport A=plain\nB='single $quoted'\nC = \"tab\\t\"\n"
+		got, err := DecodeValues(strings.NewReader(in), "dotenv")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"A": "plain", "B": "single $quoted", "C": "tab\t"}, got)
+	})
+
+	t.Run("it reads numbers and booleans in yaml as strings", func(t *testing.T) {
+		got, err := DecodeValues(strings.NewReader("port: 5432\ndebug: true\n"), "yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"port": "5432", "debug": "true"}, got)
+	})
+
+	t.Run("it rejects invalid dotenv", func(t *testing.T) {
+		_, err := DecodeValues(strings.NewReader("A=1\nnoequals\n"), "dotenv")
+		assert.Equal(t, "secret: dotenv line 2: missing '='", err.Error())
+		_, err = DecodeValues(strings.NewReader("A B=1\n"), "dotenv")
+		assert.NotNil(t, err)
+		err = EncodeValues(&bytes.Buffer{}, "dotenv", map[string]string{"a key": "x"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it rejects unknown formats", func(t *testing.T) {
+		assert.NotNil(t, EncodeValues(&bytes.Buffer{}, "xml", values))
+		_, err := DecodeValues(strings.NewReader(""), "xml")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it guesses the format from the file name", func(t *testing.T) {
+		assert.Equal(t, "json", FormatFromPath("out.JSON"))
+		assert.Equal(t, "yaml", FormatFromPath("out.yml"))
+		assert.Equal(t, "dotenv", FormatFromPath(".env"))
+		assert.Equal(t, "dotenv", FormatFromPath(""))
+	})
+}
@@ -0,0 +1,161 @@
+package secret
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Character sets Generate draws from. With CharsetWords the length is a
+// number of words, each carrying 11 bits.
+const (
+	CharsetAlnum  = "alnum"
+	CharsetHex    = "hex"
+	CharsetBase64 = "base64"
+	CharsetWords  = "words"
+)
+
+var alphabets = map[string]string{
+	CharsetAlnum:  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	CharsetHex:    "0123456789abcdef",
+	CharsetBase64: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+}
+
+// words is the BIP 39 English word list.
+//
+//go:embed words.txt
+var wordList string
+var words = strings.Fields(wordList)
+
+// ErrNoPolicy is returned by Rotate for a key without a rotation policy.
+var ErrNoPolicy = errors.New("secret: no rotation policy for that key")
+
+// Policy says how the value of a key is generated and, with a MaxAge,
+// how old it may get before RotateDue replaces it.
+type Policy struct {
+	Charset string        `json:"charset"`
+	Length  int           `json:"length"`
+	MaxAge  time.Duration `json:"max_age,omitempty"`
+}
+
+// Generate returns a random value of length characters from charset,
+// or of length words joined by "-" for CharsetWords.
+func Generate(length int, charset string) (string, error) {
+	if length <= 0 {
+		return "", errors.New("secret: length must be positive")
+	}
+	if charset == CharsetWords {
+		picked := make([]string, length)
+		for i := range picked {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+			if err != nil {
+				return "", err
+			}
+			picked[i] = words[n.Int64()]
+		}
+		return strings.Join(picked, "-"), nil
+	}
+	alphabet, ok := alphabets[charset]
+	if !ok {
+		return "", fmt.Errorf("secret: unknown charset %q, use alnum, hex, base64 or words", charset)
+	}
+	value := make([]byte, length)
+	for i := range value {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		value[i] = alphabet[n.Int64()]
+	}
+	return string(value), nil
+}
+
+// Generate returns a new value following the policy.
+func (p Policy) Generate() (string, error) {
+	return Generate(p.Length, p.Charset)
+}
+
+// RotationDue reports whether the entry has a rotation policy whose max
+// age has elapsed at t.
+func (e Entry) RotationDue(t time.Time) bool {
+	return e.Rotation != nil && e.Rotation.MaxAge > 0 && !e.Updated.Add(e.Rotation.MaxAge).After(t)
+}
+
+// WithPolicy sets how the entry is generated and rotated.
+func WithPolicy(p Policy) SetOption {
+	return func(e *Entry) {
+		e.Rotation = &p
+	}
+}
+
+// Rotate replaces the value of key with a new one generated from its
+// policy, keeping the old value in the history.
+func (v *Vault) Rotate(key string) error {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return err
+	}
+	e, ok := v.keyValues[key]
+	if !ok {
+		return v.record("rotate", key, ErrNoValue)
+	}
+	if e.Rotation == nil {
+		return v.record("rotate", key, ErrNoPolicy)
+	}
+	value, err := e.Rotation.Generate()
+	if err != nil {
+		return err
+	}
+	e.update(value, now(), v.retentionCount())
+	return v.record("rotate", key, v.Save())
+}
+
+// RotateDue rotates every key whose policy has elapsed and returns their
+// names in order.
+func (v *Vault) RotateDue() ([]string, error) {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return nil, err
+	}
+	t := now()
+	var rotated []string
+	for key, e := range v.keyValues {
+		if !e.RotationDue(t) {
+			continue
+		}
+		value, err := e.Rotation.Generate()
+		if err != nil {
+			// keys rotated so far are only changed in memory
+			v.loaded = false
+			return nil, err
+		}
+		e.update(value, t, v.retentionCount())
+		rotated = append(rotated, key)
+	}
+	sort.Strings(rotated)
+	if len(rotated) == 0 {
+		return nil, nil
+	}
+	err = v.Save()
+	for _, key := range rotated {
+		if rerr := v.record("rotate", key, err); rerr != nil {
+			return nil, rerr
+		}
+	}
+	return rotated, nil
+}
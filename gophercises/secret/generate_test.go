@@ -0,0 +1,90 @@
+package secret
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	for charset, alphabet := range alphabets {
+		value, err := Generate(40, charset)
+		assert.Nil(t, err)
+		assert.Len(t, value, 40)
+		for _, c := range value {
+			assert.True(t, strings.ContainsRune(alphabet, c), "%q not in %s", c, charset)
+		}
+	}
+
+	value, err := Generate(4, CharsetWords)
+	assert.Nil(t, err)
+	picked := strings.Split(value, "-")
+	assert.Len(t, picked, 4)
+	for _, w := range picked {
+		assert.Contains(t, words, w)
+	}
+	assert.Len(t, words, 2048)
+
+	_, err = Generate(0, CharsetHex)
+	assert.NotNil(t, err)
+	_, err = Generate(8, "emoji")
+	assert.NotNil(t, err)
+}
+
+func TestRotate(t *testing.T) {
+	defer func() { now = time.Now }()
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := Policy{Charset: CharsetHex, Length: 16, MaxAge: 24 * time.Hour}
+
+	v := InitFile()
+	defer removeVault(v)
+	now = func() time.Time { return t0 }
+	assert.Nil(t, v.Set("db_password", "initial", WithPolicy(policy)))
+	assert.Nil(t, v.Set("api_key", "fixed"))
+	assert.Nil(t, v.Set("short_lived", "initial", WithPolicy(Policy{Charset: CharsetAlnum, Length: 8, MaxAge: time.Hour})))
+
+	t.Run("it rotates only keys whose policy elapsed", func(t *testing.T) {
+		now = func() time.Time { return t0.Add(2 * time.Hour) }
+		rotated, err := v.RotateDue()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"short_lived"}, rotated)
+
+		now = func() time.Time { return t0.Add(150 * time.Minute) }
+		rotated, err = v.RotateDue()
+		assert.Nil(t, err)
+		assert.Empty(t, rotated)
+
+		now = func() time.Time { return t0.Add(25 * time.Hour) }
+		rotated, err = v.RotateDue()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"db_password", "short_lived"}, rotated)
+
+		e, _ := v.GetEntry("db_password")
+		assert.Len(t, e.Value, 16)
+		assert.Equal(t, 2, e.Version)
+		assert.Equal(t, "initial", e.History[0].Value)
+		assert.Equal(t, policy, *e.Rotation)
+	})
+
+	t.Run("it rotates a key on demand", func(t *testing.T) {
+		assert.Nil(t, v.Rotate("db_password"))
+		e, _ := v.GetEntry("db_password")
+		assert.Equal(t, 3, e.Version)
+		assert.Equal(t, ErrNoPolicy, v.Rotate("api_key"))
+		assert.Equal(t, ErrNoValue, v.Rotate("missing"))
+	})
+
+	t.Run("it records rotations in the audit log", func(t *testing.T) {
+		records, err := v.AuditLog()
+		assert.Nil(t, err)
+		var keys []string
+		for _, rec := range records {
+			if rec.Op == "rotate" && rec.Error == "" {
+				keys = append(keys, rec.Key)
+			}
+		}
+		assert.Equal(t, []string{"short_lived", "db_password", "short_lived", "db_password"}, keys)
+	})
+}
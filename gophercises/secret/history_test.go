@@ -0,0 +1,82 @@
+package secret
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("it keeps previous versions newest first", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		for _, value := range []string{"one", "two", "three"} {
+			assert.Nil(t, v.Set("test_key", value))
+		}
+		e, err := v.GetEntry("test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, e.Version)
+		assert.Equal(t, "three", e.Value)
+		assert.Len(t, e.History, 2)
+		assert.Equal(t, Version{Version: 2, Value: "two", Updated: e.History[0].Updated}, e.History[0])
+		assert.Equal(t, "one", e.History[1].Value)
+	})
+
+	t.Run("it doesn't add a version when the value is unchanged", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", "one"))
+		assert.Nil(t, v.Set("test_key", "one", WithDescription("same")))
+		e, _ := v.GetEntry("test_key")
+		assert.Equal(t, 1, e.Version)
+		assert.Empty(t, e.History)
+	})
+
+	t.Run("it keeps only the configured number of versions", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.SetRetention(2))
+		for i := 0; i < 5; i++ {
+			assert.Nil(t, v.Set("test_key", string(rune('a'+i))))
+		}
+		e, _ := v.GetEntry("test_key")
+		assert.Len(t, e.History, 2)
+		assert.Equal(t, 4, e.History[0].Version)
+
+		n, err := File(v.encodingKey, v.filepath).Retention()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+
+		assert.Nil(t, v.SetRetention(0))
+		e, _ = v.GetEntry("test_key")
+		assert.Empty(t, e.History)
+		assert.NotNil(t, v.SetRetention(-1))
+	})
+
+	t.Run("it uses the default retention for new vaults", func(t *testing.T) {
+		n, err := InitFile().Retention()
+		assert.Nil(t, err)
+		assert.Equal(t, DefaultRetention, n)
+	})
+}
+
+func TestRollback(t *testing.T) {
+	v := InitFile()
+	defer os.Remove(v.filepath)
+	assert.Nil(t, v.Set("test_key", "one"))
+	assert.Nil(t, v.Set("test_key", "two"))
+
+	t.Run("it restores a previous version as a new version", func(t *testing.T) {
+		assert.Nil(t, v.Rollback("test_key", 1))
+		e, _ := v.GetEntry("test_key")
+		assert.Equal(t, "one", e.Value)
+		assert.Equal(t, 3, e.Version)
+		assert.Equal(t, "two", e.History[0].Value)
+	})
+
+	t.Run("it fails for unknown versions and keys", func(t *testing.T) {
+		assert.Equal(t, ErrNoVersion, v.Rollback("test_key", 9))
+		assert.Equal(t, ErrNoValue, v.Rollback("missing_key", 1))
+	})
+}
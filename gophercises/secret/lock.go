@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultLockTimeout is how long vault operations wait for another process
+// to release the vault before giving up.
+const DefaultLockTimeout = 10 * time.Second
+
+var lockRetryInterval = 10 * time.Millisecond
+
+// ErrLockTimeout is returned when the vault lock can't be acquired in time.
+var ErrLockTimeout = errors.New("secret: timed out waiting for the vault lock")
+
+var errWouldBlock = errors.New("secret: lock is held by another process")
+
+// fileLock is an advisory lock shared by every process using the vault.
+// It lives in a separate ".lock" file because Save replaces the vault
+// file itself.
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile locks path, shared or exclusive, retrying until timeout.
+func lockFile(path string, exclusive bool, timeout time.Duration) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := flock(f, exclusive)
+		if err == nil {
+			return &fileLock{f: f}, nil
+		}
+		if err != errWouldBlock {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s is held by another process after %s", ErrLockTimeout, path, timeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func (l *fileLock) unlock() error {
+	err := funlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
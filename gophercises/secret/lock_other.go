@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package secret
+
+import "os"
+
+// flock is a no-op where flock(2) is unavailable; only the in-process
+// mutex protects the vault there.
+func flock(f *os.File, exclusive bool) error {
+	return nil
+}
+
+func funlock(f *os.File) error {
+	return nil
+}
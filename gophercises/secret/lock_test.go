@@ -0,0 +1,134 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const helperWrites = 10
+
+// TestLockHelperProcess is not a real test. TestConcurrentWriters runs the
+// test binary again with SECRET_HELPER_VAULT set to make it act as a
+// separate `secret set` process.
+func TestLockHelperProcess(t *testing.T) {
+	path := os.Getenv("SECRET_HELPER_VAULT")
+	if path == "" {
+		return
+	}
+	id := os.Getenv("SECRET_HELPER_ID")
+	v := File("testencodingKey", path)
+	v.SetLockTimeout(time.Minute)
+	for i := 0; i < helperWrites; i++ {
+		if err := v.Set(fmt.Sprintf("writer%s_%d", id, i), id); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(0)
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets")
+
+	t.Run("it loses no updates across processes", func(t *testing.T) {
+		const writers = 4
+		var wg sync.WaitGroup
+		errs := make(chan error, writers)
+		for w := 0; w < writers; w++ {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+			cmd.Env = append(os.Environ(), "SECRET_HELPER_VAULT="+path, "SECRET_HELPER_ID="+strconv.Itoa(w))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if out, err := cmd.CombinedOutput(); err != nil {
+					errs <- fmt.Errorf("%v: %s", err, out)
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatal(err)
+		}
+
+		v := File("testencodingKey", path)
+		assert.Nil(t, v.Load())
+		assert.Len(t, v.keyValues, writers*helperWrites)
+	})
+
+	t.Run("it loses no updates across goroutines", func(t *testing.T) {
+		v := File("testencodingKey", filepath.Join(dir, "shared"))
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.Nil(t, v.Set(fmt.Sprintf("key_%d", i), "value"))
+			}(i)
+		}
+		wg.Wait()
+		assert.Nil(t, v.Load())
+		assert.Len(t, v.keyValues, 8)
+	})
+}
+
+func TestLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets")
+
+	t.Run("it allows several shared holders", func(t *testing.T) {
+		a, err := lockFile(path+".lock", false, 0)
+		assert.Nil(t, err)
+		b, err := lockFile(path+".lock", false, 0)
+		assert.Nil(t, err)
+		assert.Nil(t, a.unlock())
+		assert.Nil(t, b.unlock())
+	})
+
+	t.Run("it times out while another process writes", func(t *testing.T) {
+		held, err := lockFile(path+".lock", true, 0)
+		assert.Nil(t, err)
+		defer held.unlock()
+
+		v := File("testencodingKey", path)
+		v.SetLockTimeout(50 * time.Millisecond)
+		_, err = v.Get("test_key")
+		assert.True(t, errors.Is(err, ErrLockTimeout))
+		err = v.Set("test_key", "value")
+		assert.True(t, errors.Is(err, ErrLockTimeout))
+	})
+
+	t.Run("it blocks writers while a reader holds the lock", func(t *testing.T) {
+		held, err := lockFile(path+".lock", false, 0)
+		assert.Nil(t, err)
+		defer held.unlock()
+
+		_, err = lockFile(path+".lock", true, 20*time.Millisecond)
+		assert.True(t, errors.Is(err, ErrLockTimeout))
+	})
+
+	t.Run("it returns error if the lock file can't be created", func(t *testing.T) {
+		_, err := lockFile(filepath.Join(dir, "missing", "secrets.lock"), true, 0)
+		assert.NotNil(t, err)
+		assert.False(t, errors.Is(err, ErrLockTimeout))
+	})
+}
@@ -0,0 +1,127 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultNamespace is the namespace kept in the base vault file itself.
+// Every other namespace is a separate vault file in the base path with a
+// ".d" suffix, so each one can be sealed with its own encoding key. Other
+// stores keep every namespace as a blob of the same name.
+const DefaultNamespace = "default"
+
+var (
+	// ErrNamespaceExists is returned when creating a namespace twice.
+	ErrNamespaceExists = errors.New("secret: namespace already exists")
+	// ErrNoNamespace is returned for a namespace that was never created.
+	ErrNoNamespace = errors.New("secret: no such namespace")
+)
+
+var namespaceName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// CheckNamespace returns an error for a name that can't be used as a
+// namespace, including names of the files kept next to another vault.
+func CheckNamespace(ns string) error {
+	if !namespaceName.MatchString(ns) {
+		return fmt.Errorf("secret: invalid namespace name %q", ns)
+	}
+	if isAuxFile(ns) || strings.HasSuffix(ns, ".d") {
+		return fmt.Errorf("secret: namespace name %q is reserved for the files of another vault", ns)
+	}
+	return nil
+}
+
+// NamespacePath returns the vault file holding namespace ns of the store
+// whose default vault is base.
+func NamespacePath(base, ns string) (string, error) {
+	if ns == "" || ns == DefaultNamespace {
+		return base, nil
+	}
+	if err := CheckNamespace(ns); err != nil {
+		return "", err
+	}
+	return filepath.Join(namespaceDir(base), ns), nil
+}
+
+func namespaceDir(base string) string {
+	return base + ".d"
+}
+
+// Namespaces lists the namespaces of s. The default namespace is always
+// listed.
+func Namespaces(s Store) ([]string, error) {
+	stored, err := s.Names()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{DefaultNamespace}
+	for _, name := range stored {
+		if name != DefaultNamespace {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names, nil
+}
+
+// isAuxFile reports whether name is a lock, backup, audit or temporary
+// file kept next to a vault rather than a vault itself.
+func isAuxFile(name string) bool {
+	return strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".bak") ||
+		strings.HasSuffix(name, ".audit") || strings.HasSuffix(name, ".audit.head") ||
+		strings.Contains(name, ".tmp")
+}
+
+// CreateNamespace creates an empty namespace of s sealed with
+// encodingKey.
+func CreateNamespace(s Store, ns, encodingKey string) error {
+	if err := CheckNamespace(ns); err != nil {
+		return err
+	}
+	v := New(encodingKey, s, ns)
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, _, err := s.Read(ns)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		return ErrNamespaceExists
+	}
+	return v.record("namespace create", "", v.Save())
+}
+
+// DeleteNamespace removes a namespace of s, its backup and audit log.
+// The lock file is left in place so processes waiting on it stay
+// serialised. The default namespace can't be deleted.
+func DeleteNamespace(s Store, ns string) error {
+	if ns == "" || ns == DefaultNamespace {
+		return errors.New("secret: the default namespace can't be deleted")
+	}
+	if err := CheckNamespace(ns); err != nil {
+		return err
+	}
+	v := New("", s, ns)
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := s.Delete(ns); err != nil {
+		return err
+	}
+	if v.filepath != "" {
+		os.Remove(v.auditPath())
+		os.Remove(v.auditHeadPath())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	Cipher "gophercises/secret/cipher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacePath(t *testing.T) {
+	t.Run("it keeps the default namespace in the base file", func(t *testing.T) {
+		path, err := NamespacePath("/home/x/.secrets", "")
+		assert.Nil(t, err)
+		assert.Equal(t, "/home/x/.secrets", path)
+		path, _ = NamespacePath("/home/x/.secrets", DefaultNamespace)
+		assert.Equal(t, "/home/x/.secrets", path)
+	})
+
+	t.Run("it puts other namespaces in the .d directory", func(t *testing.T) {
+		path, err := NamespacePath("/home/x/.secrets", "prod")
+		assert.Nil(t, err)
+		assert.Equal(t, "/home/x/.secrets.d/prod", path)
+	})
+
+	t.Run("it rejects names of the files kept next to a vault", func(t *testing.T) {
+		for _, ns := range []string{"q.audit", "q.audit.head", "q.lock", "q.audit.lock", "q.bak", "q.tmp123", "q.tmp", "q.d"} {
+			assert.NotNil(t, CheckNamespace(ns), ns)
+		}
+		for _, ns := range []string{"prod", "prod.eu", "q.dev", "audit"} {
+			assert.Nil(t, CheckNamespace(ns), ns)
+		}
+	})
+
+	t.Run("it rejects names that escape the directory", func(t *testing.T) {
+		for _, ns := range []string{"../prod", "a/b", ".hidden"} {
+			_, err := NamespacePath("/home/x/.secrets", ns)
+			assert.NotNil(t, err, ns)
+		}
+	})
+}
+
+func TestNamespaces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namespaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, ".secrets")
+
+	t.Run("it lists only the default namespace initially", func(t *testing.T) {
+		names, err := Namespaces(NewFileStore(base))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{DefaultNamespace}, names)
+	})
+
+	t.Run("it creates namespaces with their own key", func(t *testing.T) {
+		assert.Nil(t, CreateNamespace(NewFileStore(base), "prod", "prodkey"))
+		assert.Nil(t, CreateNamespace(NewFileStore(base), "dev", "devkey"))
+		assert.Equal(t, ErrNamespaceExists, CreateNamespace(NewFileStore(base), "prod", "otherkey"))
+
+		names, err := Namespaces(NewFileStore(base))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{DefaultNamespace, "dev", "prod"}, names)
+
+		path, _ := NamespacePath(base, "prod")
+		assert.Nil(t, File("prodkey", path).Set("db_password", "hunter2"))
+		_, err = File("devkey", path).Get("db_password")
+		assert.Equal(t, Cipher.ErrAuthentication, err)
+		value, err := File("prodkey", path).Get("db_password")
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter2", value)
+	})
+
+	t.Run("it deletes namespaces", func(t *testing.T) {
+		assert.Nil(t, DeleteNamespace(NewFileStore(base), "dev"))
+		assert.Equal(t, ErrNoNamespace, DeleteNamespace(NewFileStore(base), "dev"))
+		names, _ := Namespaces(NewFileStore(base))
+		assert.Equal(t, []string{DefaultNamespace, "prod"}, names)
+	})
+
+	t.Run("it refuses to delete the default namespace", func(t *testing.T) {
+		assert.NotNil(t, DeleteNamespace(NewFileStore(base), DefaultNamespace))
+	})
+}
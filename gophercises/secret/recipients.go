@@ -0,0 +1,139 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"gophercises/secret/cipher"
+)
+
+var (
+	// ErrNeedIdentity is returned by Load for a vault shared with
+	// recipients when no identity was set.
+	ErrNeedIdentity = errors.New("secret: the vault is shared with recipients, an identity is needed to open it")
+	// ErrNoRecipient is returned by RemoveRecipient for an unknown name.
+	ErrNoRecipient = errors.New("secret: no recipient with that name")
+)
+
+// SetIdentity sets the identity used to open a vault shared with
+// recipients. Its encoding key is not used then.
+func (v *Vault) SetIdentity(id *cipher.Identity) {
+	v.identity = id
+}
+
+// shared reports whether the loaded vault is sealed for recipients.
+func (v *Vault) shared() bool {
+	return len(v.recipients) > 0
+}
+
+func (v *Vault) recipientKeys() []string {
+	var keys []string
+	for _, r := range v.recipients {
+		keys = append(keys, r)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Recipients gives the public keys the vault is shared with by name. It
+// is empty for a vault sealed with an encoding key.
+func (v *Vault) Recipients() (map[string]string, error) {
+	unlock, err := v.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return nil, err
+	}
+	recipients := make(map[string]string, len(v.recipients))
+	for name, r := range v.recipients {
+		recipients[name] = r
+	}
+	return recipients, nil
+}
+
+// AddRecipient shares the vault with the public key recipient, wrapping
+// the current data key for it. The first recipient turns a vault sealed
+// with an encoding key into a shared one; it must be the vault's own
+// identity so nobody locks themselves out.
+func (v *Vault) AddRecipient(name, recipient string) error {
+	recipient, err := cipher.ParseRecipient(recipient)
+	if err != nil {
+		return err
+	}
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return err
+	}
+	if _, ok := v.recipients[name]; ok {
+		return fmt.Errorf("secret: a recipient named %q already exists", name)
+	}
+	for other, r := range v.recipients {
+		if r == recipient {
+			return fmt.Errorf("secret: that key already is recipient %q", other)
+		}
+	}
+	if v.shared() {
+		v.recipients[name] = recipient
+		return v.record("recipients add", name, v.Save())
+	}
+	if v.identity == nil || v.identity.Recipient() != recipient {
+		return errors.New("secret: add your own identity as the first recipient")
+	}
+	return v.reseal("recipients add", name, func() error {
+		dataKey, err := cipher.NewDataKey()
+		if err != nil {
+			return err
+		}
+		v.recipients[name], v.dataKey = recipient, dataKey
+		if err := v.Save(); err != nil {
+			v.recipients, v.dataKey = make(map[string]string), nil
+			return err
+		}
+		return nil
+	})
+}
+
+// RemoveRecipient revokes the access of recipient name. The data key is
+// rotated, so the removed identity can't read anything written after,
+// although it may have kept copies of what it could read before.
+func (v *Vault) RemoveRecipient(name string) error {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return err
+	}
+	recipient, ok := v.recipients[name]
+	if !ok {
+		return ErrNoRecipient
+	}
+	if len(v.recipients) == 1 {
+		return errors.New("secret: can't remove the last recipient, rekey the vault with an encoding key instead")
+	}
+	return v.reseal("recipients remove", name, func() error {
+		dataKey, err := cipher.NewDataKey()
+		if err != nil {
+			return err
+		}
+		oldKey := v.dataKey
+		delete(v.recipients, name)
+		v.dataKey = dataKey
+		if err := v.Save(); err != nil {
+			v.recipients[name], v.dataKey = recipient, oldKey
+			return err
+		}
+		return nil
+	})
+}
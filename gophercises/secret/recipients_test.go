@@ -0,0 +1,99 @@
+package secret
+
+import (
+	"testing"
+
+	"gophercises/secret/cipher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipients(t *testing.T) {
+	alice, _ := cipher.GenerateIdentity()
+	bob, _ := cipher.GenerateIdentity()
+	open := func(id *cipher.Identity, v *Vault) *Vault {
+		other := File("", v.filepath)
+		other.SetIdentity(id)
+		return other
+	}
+
+	t.Run("the first recipient must be the vault's own identity", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		assert.NotNil(t, v.AddRecipient("bob", bob.Recipient()))
+		v.SetIdentity(alice)
+		assert.NotNil(t, v.AddRecipient("bob", bob.Recipient()))
+		assert.NotNil(t, v.AddRecipient("alice", "secretpub1bad"))
+	})
+
+	t.Run("it shares the vault and rotates the data key on removal", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.SetIdentity(alice)
+		assert.Nil(t, v.Set("db_password", "hunter2"))
+		assert.Nil(t, v.AddRecipient("alice", alice.Recipient()))
+		assert.Nil(t, v.AddRecipient("bob", bob.Recipient()))
+
+		value, err := open(bob, v).Get("db_password")
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter2", value)
+		_, err = File("testencodingKey", v.filepath).Get("db_password")
+		assert.Equal(t, ErrNeedIdentity, err)
+
+		bobs := open(bob, v)
+		bobs.Load()
+		oldKey := bobs.dataKey
+
+		assert.Nil(t, v.RemoveRecipient("bob"))
+		_, err = open(bob, v).Get("db_password")
+		assert.Equal(t, cipher.ErrNoIdentity, err)
+		assert.Nil(t, v.Set("db_password", "rotated"))
+		assert.Nil(t, v.Load())
+		assert.NotEqual(t, oldKey, v.dataKey)
+
+		recipients, err := v.Recipients()
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"alice": alice.Recipient()}, recipients)
+		assert.NotNil(t, v.RemoveRecipient("alice"))
+		assert.Equal(t, ErrNoRecipient, v.RemoveRecipient("bob"))
+	})
+
+	t.Run("it keeps the audit log readable across rotations", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.SetIdentity(alice)
+		v.Set("a", "1")
+		assert.Nil(t, v.AddRecipient("alice", alice.Recipient()))
+		assert.Nil(t, v.AddRecipient("bob", bob.Recipient()))
+		assert.Nil(t, v.RemoveRecipient("bob"))
+
+		records, err := v.AuditLog()
+		assert.Nil(t, err)
+		assert.Len(t, records, 4)
+		assert.Equal(t, "recipients remove", records[3].Op)
+	})
+
+	t.Run("rekey seals it with an encoding key again", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.SetIdentity(alice)
+		v.Set("a", "1")
+		assert.Nil(t, v.AddRecipient("alice", alice.Recipient()))
+		assert.Nil(t, v.Rekey("newkey", v.kdf))
+		value, err := File("newkey", v.filepath).Get("a")
+		assert.Nil(t, err)
+		assert.Equal(t, "1", value)
+	})
+
+	t.Run("rekey needs a new encoding key", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		v.SetIdentity(alice)
+		v.Set("a", "1")
+		assert.Nil(t, v.AddRecipient("alice", alice.Recipient()))
+		assert.NotNil(t, v.Rekey("", v.kdf))
+		recipients, err := v.Recipients()
+		assert.Nil(t, err)
+		assert.Len(t, recipients, 1)
+	})
+}
@@ -0,0 +1,54 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+// Render executes the text/template text, named name in errors, with
+// these functions reading the vault:
+//
+//	{{ secret "db_password" }}          the value of a key, failing if unset
+//	{{ secretOr "port" "5432" }}        the value of a key or a default
+//
+// Nothing is written to w when the template fails, so a missing key
+// never leaves a half rendered file behind.
+func (v *Vault) Render(w io.Writer, name, text string) error {
+	values, err := v.Values()
+	if err != nil {
+		return err
+	}
+	funcs := template.FuncMap{
+		"secret": func(key string) (string, error) {
+			value, ok := values[key]
+			if !ok {
+				return "", fmt.Errorf("%w: %q", ErrNoValue, key)
+			}
+			return value, nil
+		},
+		"secretOr": func(key, def string) string {
+			if value, ok := values[key]; ok {
+				return value
+			}
+			return def
+		},
+	}
+	t, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, nil); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// WritePrivateFile atomically replaces path with data, readable and
+// writable by its owner only.
+func WritePrivateFile(path string, data []byte) error {
+	return writeFileAtomic(path, data, 0600, false)
+}
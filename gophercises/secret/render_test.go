@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	v := InitFile()
+	defer removeVault(v)
+	assert.Nil(t, v.Set("db_password", "hunter2"))
+
+	t.Run("it fills in secrets and defaults", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := v.Render(&buf, "config", `password: {{ secret "db_password" }}
+port: {{ secretOr "db_port" "5432" }}
+`)
+		assert.Nil(t, err)
+		assert.Equal(t, "password: hunter2\nport: 5432\n", buf.String())
+	})
+
+	t.Run("it fails on a missing key without writing", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := v.Render(&buf, "config", `a: {{ secretOr "x" "y" }} b: {{ secret "missing" }}`)
+		assert.True(t, errors.Is(err, ErrNoValue))
+		assert.Contains(t, err.Error(), `"missing"`)
+		assert.Zero(t, buf.Len())
+	})
+
+	t.Run("it reports template syntax errors", func(t *testing.T) {
+		err := v.Render(ioutil.Discard, "config", `{{ secret `)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestWritePrivateFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "render")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("old"), 0644))
+
+	assert.Nil(t, WritePrivateFile(path, []byte("new")))
+	data, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "new", string(data))
+	info, _ := os.Stat(path)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -0,0 +1,114 @@
+// Package shamir implements Shamir's secret sharing over GF(256).
+//
+// Every byte of the secret is the constant term of its own random
+// polynomial of degree threshold-1; a share holds the value of every
+// polynomial at the share's x coordinate, which is appended as the last
+// byte. Any threshold shares recover the secret by Lagrange
+// interpolation at zero, fewer reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrInvalidParams is returned by Split for impossible share counts.
+	ErrInvalidParams = errors.New("shamir: need 2 <= threshold <= shares <= 255")
+	// ErrInvalidShares is returned by Combine for shares that can't come
+	// from the same Split.
+	ErrInvalidShares = errors.New("shamir: shares have different lengths or repeat an x coordinate")
+)
+
+// random is where the polynomial coefficients come from.
+var random io.Reader = rand.Reader
+
+// Split divides secret into n shares of which any threshold recover it.
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, ErrInvalidParams
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("shamir: empty secret")
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+	coefficients := make([]byte, threshold)
+	for b, value := range secret {
+		coefficients[0] = value
+		if _, err := io.ReadFull(random, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			share[b] = evaluate(coefficients, share[len(secret)])
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from shares. Given fewer shares than the
+// threshold it returns garbage rather than an error, since that can't be
+// told from the shares alone.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("shamir: need at least two shares")
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, ErrInvalidShares
+	}
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]bool)
+	for i, s := range shares {
+		if len(s) != size {
+			return nil, ErrInvalidShares
+		}
+		x := s[size-1]
+		if x == 0 || seen[x] {
+			return nil, ErrInvalidShares
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+	secret := make([]byte, size-1)
+	ys := make([]byte, len(shares))
+	for b := range secret {
+		for i, s := range shares {
+			ys[i] = s[b]
+		}
+		secret[b] = interpolateAtZero(xs, ys)
+	}
+	return secret, nil
+}
+
+// evaluate computes the polynomial with the given coefficients, lowest
+// degree first, at x using Horner's rule.
+func evaluate(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = add(mul(y, x), coefficients[i])
+	}
+	return y
+}
+
+// interpolateAtZero returns the value at zero of the polynomial through
+// the points (xs[i], ys[i]).
+func interpolateAtZero(xs, ys []byte) byte {
+	var result byte
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			// (0 - xj) / (xi - xj); subtraction is addition in GF(256).
+			basis = mul(basis, div(xs[j], add(xs[i], xs[j])))
+		}
+		result = add(result, mul(ys[i], basis))
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// subsets calls f with every subset of shares.
+func subsets(shares [][]byte, f func([][]byte)) {
+	for mask := 1; mask < 1<<len(shares); mask++ {
+		var subset [][]byte
+		for i := range shares {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, shares[i])
+			}
+		}
+		f(subset)
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	for n := 2; n <= 7; n++ {
+		for threshold := 2; threshold <= n; threshold++ {
+			shares, err := Split(secret, n, threshold)
+			assert.Nil(t, err)
+			assert.Len(t, shares, n)
+			subsets(shares, func(subset [][]byte) {
+				if len(subset) < 2 {
+					return
+				}
+				got, err := Combine(subset)
+				assert.Nil(t, err)
+				if len(subset) >= threshold {
+					assert.Equal(t, secret, got, "n=%d threshold=%d with %d shares", n, threshold, len(subset))
+				} else {
+					assert.NotEqual(t, secret, got, "n=%d threshold=%d with %d shares", n, threshold, len(subset))
+				}
+			})
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Run("it rejects impossible parameters", func(t *testing.T) {
+		for _, p := range [][2]int{{3, 1}, {2, 3}, {256, 3}} {
+			_, err := Split([]byte("x"), p[0], p[1])
+			assert.Equal(t, ErrInvalidParams, err)
+		}
+		_, err := Split(nil, 3, 2)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it uses fresh coefficients every time", func(t *testing.T) {
+		a, _ := Split([]byte("same"), 3, 2)
+		b, _ := Split([]byte("same"), 3, 2)
+		assert.NotEqual(t, a[0], b[0])
+	})
+
+	t.Run("it returns error if the random source fails", func(t *testing.T) {
+		defer func(r io.Reader) { random = r }(random)
+		random = bytes.NewReader(nil)
+		_, err := Split([]byte("x"), 3, 2)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCombine(t *testing.T) {
+	shares, _ := Split([]byte("secret"), 3, 2)
+
+	t.Run("it rejects mismatched or repeated shares", func(t *testing.T) {
+		_, err := Combine([][]byte{shares[0]})
+		assert.NotNil(t, err)
+		_, err = Combine([][]byte{shares[0], shares[0]})
+		assert.Equal(t, ErrInvalidShares, err)
+		_, err = Combine([][]byte{shares[0], shares[1][1:]})
+		assert.Equal(t, ErrInvalidShares, err)
+		zero := append([]byte(nil), shares[1]...)
+		zero[len(zero)-1] = 0
+		_, err = Combine([][]byte{shares[0], zero})
+		assert.True(t, errors.Is(err, ErrInvalidShares))
+	})
+
+	t.Run("it doesn't modify the shares", func(t *testing.T) {
+		before := bytes.Join(shares, nil)
+		Combine(shares)
+		assert.Equal(t, before, bytes.Join(shares, nil))
+	})
+}
+
+func TestGF256(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			p := mul(byte(a), byte(b))
+			if p != mulSlow(byte(a), byte(b)) || div(p, byte(b)) != byte(a) {
+				t.Fatalf("arithmetic broken for %d, %d", a, b)
+			}
+		}
+	}
+	assert.Equal(t, byte(0), mul(0, 7))
+	assert.Equal(t, byte(0), div(0, 7))
+}
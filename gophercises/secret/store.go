@@ -0,0 +1,51 @@
+package secret
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrConflict is returned by Store.Write, and so by the vault methods
+// that save, when the blob was changed since it was read.
+var ErrConflict = errors.New("secret: the vault was changed by someone else, try again")
+
+// Store keeps sealed vaults as opaque blobs, one per namespace.
+type Store interface {
+	// Read returns the blob stored under name and its version. A missing
+	// blob is nil with the empty version.
+	Read(name string) ([]byte, string, error)
+	// Write stores data under name if the stored version still is
+	// version, which is empty for a blob that must not exist yet, and
+	// returns ErrConflict otherwise.
+	Write(name string, data []byte, version string) error
+	// Delete removes the blob stored under name, or returns
+	// ErrNoNamespace.
+	Delete(name string) error
+	// Names lists the names of the stored blobs.
+	Names() ([]string, error)
+}
+
+// LocalStore is implemented by stores kept in local files. Path gives
+// the file next to which the lock and the audit log of name are kept.
+// Vaults in other stores are only locked within the process and have
+// no audit log.
+type LocalStore interface {
+	Store
+	Path(name string) string
+}
+
+// backupStore is implemented by stores that can keep the previous
+// generation of a blob.
+type backupStore interface {
+	SetBackup(backup bool)
+}
+
+// blobVersion is the version of data used for compare-and-swap.
+func blobVersion(data []byte) string {
+	if data == nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,119 @@
+package secret
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// DefaultBoltBucket is the bucket BoltStore keeps vaults in unless told
+// otherwise.
+const DefaultBoltBucket = "secrets"
+
+// BoltStore keeps every namespace as a key of a bucket in a BoltDB file,
+// which can be shared with other tools such as task. The database is
+// only opened while reading or writing, so those tools can keep using it.
+type BoltStore struct {
+	path    string
+	bucket  []byte
+	timeout time.Duration
+}
+
+// NewBoltStore returns the store kept in bucket of the BoltDB file at
+// path.
+func NewBoltStore(path, bucket string) *BoltStore {
+	return &BoltStore{path: path, bucket: []byte(bucket), timeout: time.Second}
+}
+
+// Path returns the name next to which the lock and audit log of name are
+// kept: the database file with the bucket and name appended.
+func (s *BoltStore) Path(name string) string {
+	return fmt.Sprintf("%s.%s.%s", s.path, s.bucket, name)
+}
+
+func (s *BoltStore) open() (*bolt.DB, error) {
+	return bolt.Open(s.path, 0600, &bolt.Options{Timeout: s.timeout})
+}
+
+func (s *BoltStore) Read(name string) ([]byte, string, error) {
+	if err := CheckNamespace(name); err != nil {
+		return nil, "", err
+	}
+	db, err := s.open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer db.Close()
+	var data []byte
+	err = db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(s.bucket); b != nil {
+			if v := b.Get([]byte(name)); v != nil {
+				data = append([]byte(nil), v...)
+			}
+		}
+		return nil
+	})
+	return data, blobVersion(data), err
+}
+
+func (s *BoltStore) Write(name string, data []byte, version string) error {
+	if err := CheckNamespace(name); err != nil {
+		return err
+	}
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(s.bucket)
+		if err != nil {
+			return err
+		}
+		if blobVersion(b.Get([]byte(name))) != version {
+			return ErrConflict
+		}
+		return b.Put([]byte(name), data)
+	})
+}
+
+func (s *BoltStore) Delete(name string) error {
+	if err := CheckNamespace(name); err != nil {
+		return err
+	}
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b == nil || b.Get([]byte(name)) == nil {
+			return ErrNoNamespace
+		}
+		return b.Delete([]byte(name))
+	})
+}
+
+func (s *BoltStore) Names() ([]string, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	var names []string
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			names = append(names, string(k))
+			return nil
+		})
+	})
+	sort.Strings(names)
+	return names, err
+}
@@ -0,0 +1,177 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileStore keeps the default namespace in a single file and the others
+// in a directory next to it, see NamespacePath.
+type FileStore struct {
+	base   string
+	backup bool
+}
+
+// NewFileStore returns the store whose default namespace is the file
+// base.
+func NewFileStore(base string) *FileStore {
+	return &FileStore{base: base}
+}
+
+// SetBackup makes writes keep the previous generation of a file as a
+// ".bak" next to it.
+func (s *FileStore) SetBackup(backup bool) {
+	s.backup = backup
+}
+
+// Path returns the file holding name.
+func (s *FileStore) Path(name string) string {
+	path, _ := NamespacePath(s.base, name)
+	return path
+}
+
+func (s *FileStore) Read(name string) ([]byte, string, error) {
+	path, err := NamespacePath(s.base, name)
+	if err != nil {
+		return nil, "", err
+	}
+	return readBlob(path)
+}
+
+func (s *FileStore) Write(name string, data []byte, version string) error {
+	path, err := NamespacePath(s.base, name)
+	if err != nil {
+		return err
+	}
+	return writeBlob(path, data, version, s.backup)
+}
+
+func (s *FileStore) Delete(name string) error {
+	path, err := NamespacePath(s.base, name)
+	if err != nil {
+		return err
+	}
+	return deleteBlob(path)
+}
+
+func (s *FileStore) Names() ([]string, error) {
+	names, err := listBlobs(namespaceDir(s.base))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(s.base); err == nil {
+		names = append([]string{DefaultNamespace}, names...)
+	}
+	return names, nil
+}
+
+// DirStore keeps every namespace, the default one included, as a file
+// of the same name in a directory.
+type DirStore struct {
+	dir    string
+	backup bool
+}
+
+// NewDirStore returns the store kept in dir. The directory is created
+// on the first write.
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// SetBackup makes writes keep the previous generation of a file as a
+// ".bak" next to it.
+func (s *DirStore) SetBackup(backup bool) {
+	s.backup = backup
+}
+
+// Path returns the file holding name.
+func (s *DirStore) Path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func (s *DirStore) Read(name string) ([]byte, string, error) {
+	if err := CheckNamespace(name); err != nil {
+		return nil, "", err
+	}
+	return readBlob(s.Path(name))
+}
+
+func (s *DirStore) Write(name string, data []byte, version string) error {
+	if err := CheckNamespace(name); err != nil {
+		return err
+	}
+	return writeBlob(s.Path(name), data, version, s.backup)
+}
+
+func (s *DirStore) Delete(name string) error {
+	if err := CheckNamespace(name); err != nil {
+		return err
+	}
+	return deleteBlob(s.Path(name))
+}
+
+func (s *DirStore) Names() ([]string, error) {
+	return listBlobs(s.dir)
+}
+
+func readBlob(path string) ([]byte, string, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, blobVersion(data), nil
+}
+
+// writeBlob compares and swaps the file at path. It relies on the vault
+// lock to keep other processes from writing between the two.
+func writeBlob(path string, data []byte, version string, backup bool) error {
+	_, current, err := readBlob(path)
+	if err != nil {
+		return err
+	}
+	if current != version {
+		return ErrConflict
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600, backup)
+}
+
+func deleteBlob(path string) error {
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNoNamespace
+		}
+		return err
+	}
+	os.Remove(path + ".bak")
+	return nil
+}
+
+// listBlobs lists the vault files in dir, skipping lock, backup, audit
+// and temporary files.
+func listBlobs(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !namespaceName.MatchString(name) || isAuxFile(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,55 @@
+package secret
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps vaults in memory, mostly for tests.
+type MemoryStore struct {
+	mutex sync.Mutex
+	blobs map[string][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Read(name string) ([]byte, string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data := s.blobs[name]
+	return data, blobVersion(data), nil
+}
+
+func (s *MemoryStore) Write(name string, data []byte, version string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if blobVersion(s.blobs[name]) != version {
+		return ErrConflict
+	}
+	s.blobs[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *MemoryStore) Delete(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.blobs[name]; !ok {
+		return ErrNoNamespace
+	}
+	delete(s.blobs, name)
+	return nil
+}
+
+func (s *MemoryStore) Names() ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var names []string
+	for name := range s.blobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
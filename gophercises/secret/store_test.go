@@ -0,0 +1,77 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stores := map[string]Store{
+		"file":   NewFileStore(filepath.Join(dir, ".secrets")),
+		"dir":    NewDirStore(filepath.Join(dir, "store")),
+		"bolt":   NewBoltStore(filepath.Join(dir, "tasks.db"), DefaultBoltBucket),
+		"memory": NewMemoryStore(),
+	}
+	for kind, s := range stores {
+		s := s
+		t.Run("it compares and swaps blobs in the "+kind+" store", func(t *testing.T) {
+			data, version, err := s.Read("prod")
+			assert.Nil(t, err)
+			assert.Nil(t, data)
+			assert.Equal(t, "", version)
+
+			assert.Nil(t, s.Write("prod", []byte("one"), ""))
+			assert.Equal(t, ErrConflict, s.Write("prod", []byte("two"), ""))
+			data, version, _ = s.Read("prod")
+			assert.Equal(t, "one", string(data))
+			assert.Nil(t, s.Write("prod", []byte("two"), version))
+			assert.Equal(t, ErrConflict, s.Write("prod", []byte("three"), version))
+
+			names, err := s.Names()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"prod"}, names)
+
+			assert.Nil(t, s.Delete("prod"))
+			assert.Equal(t, ErrNoNamespace, s.Delete("prod"))
+		})
+
+		t.Run("it keeps vaults and namespaces in the "+kind+" store", func(t *testing.T) {
+			v := New("test_key", s, DefaultNamespace)
+			assert.Nil(t, v.Set("db_password", "hunter2"))
+			value, err := New("test_key", s, DefaultNamespace).Get("db_password")
+			assert.Nil(t, err)
+			assert.Equal(t, "hunter2", value)
+
+			assert.Nil(t, CreateNamespace(s, "dev", "devkey"))
+			names, err := Namespaces(s)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{DefaultNamespace, "dev"}, names)
+			assert.Nil(t, DeleteNamespace(s, "dev"))
+		})
+	}
+
+	t.Run("it rejects a save over a concurrent change", func(t *testing.T) {
+		s := NewMemoryStore()
+		a, b := New("test_key", s, "ns"), New("test_key", s, "ns")
+		assert.Nil(t, a.Load())
+		assert.Nil(t, b.Load())
+		assert.Nil(t, a.Save())
+		assert.Equal(t, ErrConflict, b.Save())
+	})
+
+	t.Run("it rejects invalid names", func(t *testing.T) {
+		_, _, err := stores["dir"].Read("../x")
+		assert.NotNil(t, err)
+		assert.NotNil(t, stores["bolt"].Write("a/b", nil, ""))
+	})
+}
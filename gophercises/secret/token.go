@@ -0,0 +1,152 @@
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scopes a client token can be granted.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+var (
+	// ErrUnauthorized is returned by Authenticate for an unknown token.
+	ErrUnauthorized = errors.New("secret: invalid token")
+	// ErrNoToken is returned by RevokeToken for an unknown token name.
+	ErrNoToken = errors.New("secret: no token with that name")
+)
+
+// Token is a client credential stored in the vault. Only the SHA-256 of
+// the bearer token is kept, so reading the vault doesn't reveal it.
+type Token struct {
+	Hash    string    `json:"hash"`
+	Scopes  []string  `json:"scopes"`
+	Created time.Time `json:"created"`
+}
+
+// Allows reports whether the token was granted scope.
+func (t Token) Allows(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenID identifies a bearer token in logs without revealing it: the
+// start of its hash, as stored in Token.Hash.
+func TokenID(token string) string {
+	return hashToken(token)[:12]
+}
+
+// CreateToken stores a new client token named name with the given scopes
+// and returns the bearer token. It can't be recovered later.
+func (v *Vault) CreateToken(name string, scopes ...string) (string, error) {
+	if name == "" {
+		return "", errors.New("secret: token name can't be empty")
+	}
+	if len(scopes) == 0 {
+		return "", errors.New("secret: a token needs at least one scope")
+	}
+	for _, s := range scopes {
+		if s != ScopeRead && s != ScopeWrite {
+			return "", fmt.Errorf("secret: unknown scope %q, use read or write", s)
+		}
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := "st_" + base64.RawURLEncoding.EncodeToString(raw)
+
+	unlock, err := v.lock(true)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := v.tokens[name]; ok {
+		return "", v.record("token create", name, fmt.Errorf("secret: a token named %q already exists", name))
+	}
+	v.tokens[name] = &Token{
+		Hash:    hashToken(token),
+		Scopes:  scopes,
+		Created: now(),
+	}
+	if err := v.record("token create", name, v.Save()); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken removes the client token named name.
+func (v *Vault) RevokeToken(name string) error {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return err
+	}
+	if _, ok := v.tokens[name]; !ok {
+		return v.record("token revoke", name, ErrNoToken)
+	}
+	delete(v.tokens, name)
+	return v.record("token revoke", name, v.Save())
+}
+
+// Tokens gives the client tokens of the vault by name.
+func (v *Vault) Tokens() (map[string]Token, error) {
+	unlock, err := v.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[string]Token, len(v.tokens))
+	for name, t := range v.tokens {
+		tokens[name] = *t
+	}
+	return tokens, nil
+}
+
+// Authenticate finds the client token matching a bearer token.
+func (v *Vault) Authenticate(token string) (string, Token, error) {
+	tokens, err := v.Tokens()
+	if err != nil {
+		return "", Token{}, err
+	}
+	hash := []byte(hashToken(token))
+	found := ""
+	for name, t := range tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(t.Hash)) == 1 {
+			found = name
+		}
+	}
+	if found == "" {
+		return "", Token{}, ErrUnauthorized
+	}
+	return found, tokens[found], nil
+}
@@ -0,0 +1,69 @@
+package secret
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokens(t *testing.T) {
+	t.Run("it authenticates a created token", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		token, err := v.CreateToken("ci", ScopeRead)
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(token, "st_"))
+
+		name, tok, err := v.Authenticate(token)
+		assert.Nil(t, err)
+		assert.Equal(t, "ci", name)
+		assert.True(t, tok.Allows(ScopeRead))
+		assert.False(t, tok.Allows(ScopeWrite))
+		assert.NotContains(t, tok.Hash, token)
+
+		_, _, err = v.Authenticate("st_wrong")
+		assert.Equal(t, ErrUnauthorized, err)
+	})
+
+	t.Run("it keeps tokens next to the secrets", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", "value"))
+		_, err := v.CreateToken("ci", ScopeRead, ScopeWrite)
+		assert.Nil(t, err)
+
+		reloaded := File(v.encodingKey, v.filepath)
+		tokens, err := reloaded.Tokens()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{ScopeRead, ScopeWrite}, tokens["ci"].Scopes)
+		value, _ := reloaded.Get("test_key")
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("it rejects bad names and scopes", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		_, err := v.CreateToken("", ScopeRead)
+		assert.NotNil(t, err)
+		_, err = v.CreateToken("ci")
+		assert.NotNil(t, err)
+		_, err = v.CreateToken("ci", "admin")
+		assert.NotNil(t, err)
+		_, err = v.CreateToken("ci", ScopeRead)
+		assert.Nil(t, err)
+		_, err = v.CreateToken("ci", ScopeRead)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it revokes tokens", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		token, _ := v.CreateToken("ci", ScopeRead)
+		assert.Nil(t, v.RevokeToken("ci"))
+		_, _, err := v.Authenticate(token)
+		assert.Equal(t, ErrUnauthorized, err)
+		assert.Equal(t, ErrNoToken, v.RevokeToken("ci"))
+	})
+}
@@ -0,0 +1,118 @@
+package secret
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ConflictPolicy decides what Import does with keys the vault already has.
+type ConflictPolicy string
+
+const (
+	// Merge takes the imported value for keys present in both, keeping
+	// their description, tags, expiry and rotation policy.
+	Merge ConflictPolicy = "merge"
+	// Skip keeps every existing key with its current value and only adds
+	// new keys.
+	Skip ConflictPolicy = "skip"
+	// Overwrite replaces keys present in both with the imported value
+	// alone, dropping their description, tags, expiry and rotation
+	// policy. Keys that aren't imported are left alone either way.
+	Overwrite ConflictPolicy = "overwrite"
+)
+
+// ParseConflictPolicy returns the policy named s.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case Merge, Skip, Overwrite:
+		return p, nil
+	}
+	return "", fmt.Errorf("secret: unknown conflict policy %q, use merge, skip or overwrite", s)
+}
+
+// ImportResult lists the keys changed by Import, each sorted.
+type ImportResult struct {
+	Added   []string
+	Updated []string
+	Skipped []string
+	Removed []string
+}
+
+// Values gives the current value of every key in the vault.
+func (v *Vault) Values() (map[string]string, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(entries))
+	for key, e := range entries {
+		values[key] = e.Value
+	}
+	return values, nil
+}
+
+// Import adds values to the vault in a single write, resolving keys that
+// already exist with policy. Replaced values are kept in the history.
+// With prune, keys that aren't imported are removed, making the vault hold
+// exactly the imported keys. Every added, updated or removed key is
+// recorded in the audit log.
+func (v *Vault) Import(values map[string]string, policy ConflictPolicy, prune bool) (ImportResult, error) {
+	var res ImportResult
+	if _, err := ParseConflictPolicy(string(policy)); err != nil {
+		return res, err
+	}
+	unlock, err := v.lock(true)
+	if err != nil {
+		return res, err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return res, err
+	}
+	t := now()
+	for key, value := range values {
+		e, ok := v.keyValues[key]
+		switch {
+		case !ok:
+			e = &Entry{Created: t}
+			v.keyValues[key] = e
+			res.Added = append(res.Added, key)
+		case policy == Skip:
+			res.Skipped = append(res.Skipped, key)
+			continue
+		case e.Value != value:
+			res.Updated = append(res.Updated, key)
+		}
+		if ok && policy == Overwrite {
+			e.Description, e.Tags, e.Expires, e.Rotation = "", nil, nil, nil
+		}
+		e.update(value, t, v.retentionCount())
+	}
+	if prune {
+		for key := range v.keyValues {
+			if _, ok := values[key]; !ok {
+				delete(v.keyValues, key)
+				res.Removed = append(res.Removed, key)
+			}
+		}
+	}
+	for _, keys := range [][]string{res.Added, res.Updated, res.Skipped, res.Removed} {
+		sort.Strings(keys)
+	}
+	err = v.Save()
+	if len(res.Added)+len(res.Updated)+len(res.Removed) == 0 {
+		return res, v.record("import", "", err)
+	}
+	for _, key := range append(append([]string{}, res.Added...), res.Updated...) {
+		if rerr := v.record("import", key, err); rerr != nil {
+			return res, rerr
+		}
+	}
+	for _, key := range res.Removed {
+		if rerr := v.record("remove", key, err); rerr != nil {
+			return res, rerr
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,85 @@
+package secret
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImport(t *testing.T) {
+	setup := func() *Vault {
+		v := InitFile()
+		assert.Nil(t, v.Set("a", "old"))
+		assert.Nil(t, v.Set("b", "same"))
+		assert.Nil(t, v.Set("keep", "kept"))
+		return v
+	}
+	in := map[string]string{"a": "new", "b": "same", "c": "added"}
+
+	t.Run("it merges taking imported values", func(t *testing.T) {
+		v := setup()
+		defer os.Remove(v.filepath)
+		res, err := v.Import(in, Merge, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"c"}, res.Added)
+		assert.Equal(t, []string{"a"}, res.Updated)
+		values, _ := v.Values()
+		assert.Equal(t, map[string]string{"a": "new", "b": "same", "c": "added", "keep": "kept"}, values)
+
+		e, _ := v.GetEntry("a")
+		assert.Equal(t, "old", e.History[0].Value)
+	})
+
+	t.Run("it skips existing keys", func(t *testing.T) {
+		v := setup()
+		defer os.Remove(v.filepath)
+		res, err := v.Import(in, Skip, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a", "b"}, res.Skipped)
+		values, _ := v.Values()
+		assert.Equal(t, map[string]string{"a": "old", "b": "same", "c": "added", "keep": "kept"}, values)
+	})
+
+	t.Run("it overwrites conflicting keys only", func(t *testing.T) {
+		v := setup()
+		defer os.Remove(v.filepath)
+		v.Set("a", "old", WithDescription("described"))
+		res, err := v.Import(in, Overwrite, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a"}, res.Updated)
+		assert.Empty(t, res.Removed)
+		values, _ := v.Values()
+		assert.Equal(t, map[string]string{"a": "new", "b": "same", "c": "added", "keep": "kept"}, values)
+
+		e, _ := v.GetEntry("a")
+		assert.Equal(t, "", e.Description)
+		assert.Equal(t, "old", e.History[0].Value)
+	})
+
+	t.Run("it keeps metadata when merging", func(t *testing.T) {
+		v := setup()
+		defer os.Remove(v.filepath)
+		v.Set("a", "old", WithDescription("described"))
+		v.Import(in, Merge, false)
+		e, _ := v.GetEntry("a")
+		assert.Equal(t, "described", e.Description)
+	})
+
+	t.Run("it prunes keys that aren't imported", func(t *testing.T) {
+		v := setup()
+		defer os.Remove(v.filepath)
+		res, err := v.Import(in, Overwrite, true)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"keep"}, res.Removed)
+		values, _ := v.Values()
+		assert.Equal(t, in, values)
+	})
+
+	t.Run("it rejects unknown policies", func(t *testing.T) {
+		_, err := InitFile().Import(in, ConflictPolicy("replace"), false)
+		assert.NotNil(t, err)
+		_, err = ParseConflictPolicy("skip")
+		assert.Nil(t, err)
+	})
+}
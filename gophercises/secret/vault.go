@@ -1,118 +1,493 @@
 package secret
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"gophercises/secret/cipher"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 )
 
 // File is initialisation method for vault
 func File(encodingKey, filepath string) *Vault {
-	return &Vault{
+	return New(encodingKey, NewFileStore(filepath), DefaultNamespace)
+}
+
+// New returns the vault kept as the blob name of store.
+func New(encodingKey string, store Store, name string) *Vault {
+	v := &Vault{
 		encodingKey: encodingKey,
-		filepath:    filepath,
-		keyValues:   make(map[string]string),
+		store:       store,
+		name:        name,
+		keyValues:   make(map[string]*Entry),
 	}
+	if s, ok := store.(LocalStore); ok {
+		v.filepath = s.Path(name)
+	}
+	return v
 }
 
 // Vault is a struct which defines secret key parameters
 type Vault struct {
 	encodingKey string
-	filepath    string
+	store       Store
+	name        string
+	// filepath is where the lock and audit log are kept, empty for a
+	// store that isn't local. A vault without a store keeps the default
+	// namespace in the file at filepath.
+	filepath string
+	version  string
+	// loaded is set while the vault in memory is what the store holds at
+	// version, so Load can skip decrypting it again
+	loaded      bool
+	lockTimeout time.Duration
+	kdf         cipher.KDFParams
 	mutex       sync.Mutex
-	keyValues   map[string]string
+	retention   *int
+	tokens      map[string]*Token
+	keyValues   map[string]*Entry
+
+	// identity opens vaults shared with recipients, whose entries are
+	// sealed with dataKey
+	identity   *cipher.Identity
+	dataKey    []byte
+	recipients map[string]string
+
+	// auditAs is recorded as the user in the audit log, see SetAuditUser
+	auditAs string
+	// auditAnchor is the head of the audit log as of the last change,
+	// stored in the document; resealing keeps Save from moving it
+	auditAnchor *auditHead
+	resealing   bool
+	// the last derived audit log key, see deriveAuditKey
+	auditKey   []byte
+	auditKeyOf string
+	auditSalt  []byte
+}
+
+// SetBackup makes Save keep the previous generation of the file as a
+// ".bak" next to it.
+func (v *Vault) SetBackup(backup bool) {
+	if s, ok := v.blobs().(backupStore); ok {
+		s.SetBackup(backup)
+	}
+}
+
+func (v *Vault) blobs() Store {
+	if v.store == nil {
+		v.store, v.name = NewFileStore(v.filepath), DefaultNamespace
+	}
+	return v.store
+}
+
+// SetLockTimeout sets how long Get, Set and Remove wait for other
+// processes to release the vault. Zero means DefaultLockTimeout.
+func (v *Vault) SetLockTimeout(timeout time.Duration) {
+	v.lockTimeout = timeout
+}
+
+// lock takes the in-process mutex and, for local stores, the advisory
+// file lock shared with other processes. The returned function releases
+// both.
+func (v *Vault) lock(exclusive bool) (func(), error) {
+	v.mutex.Lock()
+	if !v.local() {
+		return v.mutex.Unlock, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(v.filepath), 0700); err != nil {
+		v.mutex.Unlock()
+		return nil, err
+	}
+	l, err := lockFile(v.filepath+".lock", exclusive, v.timeout())
+	if err != nil {
+		v.mutex.Unlock()
+		return nil, err
+	}
+	return func() {
+		l.unlock()
+		v.mutex.Unlock()
+	}, nil
+}
+
+func (v *Vault) local() bool {
+	_, ok := v.blobs().(LocalStore)
+	return ok
+}
+
+func (v *Vault) timeout() time.Duration {
+	if v.lockTimeout == 0 {
+		return DefaultLockTimeout
+	}
+	return v.lockTimeout
 }
 
-//Load : test
+// Load reads and decrypts the vault file. Files in the sealed format are
+// authenticated, so a wrong key or a modified file is reported as
+// cipher.ErrAuthentication. Older CFB files are still readable and are
+// upgraded on the next Save.
+// A vault that was loaded or saved before is only decrypted again once
+// the stored version changed, so the key derivation doesn't run on every
+// call of a long-lived vault.
 func (v *Vault) Load() error {
-	f, err := os.Open(v.filepath)
+	data, version, err := v.blobs().Read(v.name)
 	if err != nil {
-		v.keyValues = make(map[string]string)
+		return err
+	}
+	if v.loaded && version == v.version {
+		return nil
+	}
+	v.version, v.loaded = version, false
+	if err := v.open(data); err != nil {
+		return err
+	}
+	v.loaded = true
+	return nil
+}
+
+// open decrypts data, the stored vault, into v.
+func (v *Vault) open(data []byte) error {
+	if data == nil {
+		v.reset()
+		return nil
+	}
+	if cipher.IsShared(data) {
+		if v.identity == nil {
+			return ErrNeedIdentity
+		}
+		plaintext, dataKey, err := cipher.OpenFor(v.identity, data)
+		if err != nil {
+			return err
+		}
+		if err := v.readKeyValues(bytes.NewReader(plaintext)); err != nil {
+			return err
+		}
+		v.dataKey = dataKey
 		return nil
 	}
-	defer f.Close()
-	r, err := cipher.NewDecryptReader(v.encodingKey, f)
+	if cipher.IsSealed(data) {
+		plaintext, err := cipher.Open(v.encodingKey, data)
+		if err != nil {
+			return err
+		}
+		h, _ := cipher.ReadHeader(data)
+		v.kdf = h.KDF
+		return v.readKeyValues(bytes.NewReader(plaintext))
+	}
+	r, err := cipher.NewDecryptReader(v.encodingKey, bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
-	return v.readKeyValues(r)
+	if err := v.readKeyValues(r); err != nil {
+		return fmt.Errorf("secret: unable to read legacy vault, check the encoding key: %v", err)
+	}
+	return nil
 }
 
 func (v *Vault) readKeyValues(r io.Reader) error {
-	dec := json.NewDecoder(r)
-	err := dec.Decode(&v.keyValues)
-	if err == io.EOF {
-		v.keyValues = make(map[string]string)
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	v.reset()
+	if len(bytes.TrimSpace(data)) == 0 {
 		return nil
 	}
-	return err
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	// Values of a flat legacy vault are always strings, so a "secrets"
+	// object can only come from a document.
+	if secrets, ok := raw["secrets"]; !ok || !bytes.HasPrefix(bytes.TrimSpace(secrets), []byte("{")) {
+		return json.Unmarshal(data, &v.keyValues)
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Secrets != nil {
+		v.keyValues = doc.Secrets
+	}
+	if doc.Tokens != nil {
+		v.tokens = doc.Tokens
+	}
+	if doc.Recipients != nil {
+		v.recipients = doc.Recipients
+	}
+	v.retention = doc.Retention
+	v.auditAnchor = doc.Audit
+	return nil
 }
 
-//Save Save
+// reset empties the vault before it is loaded.
+func (v *Vault) reset() {
+	v.keyValues = make(map[string]*Entry)
+	v.tokens = make(map[string]*Token)
+	v.recipients = make(map[string]string)
+	v.dataKey = nil
+	v.retention = nil
+	v.auditAnchor = nil
+}
+
+// Save encrypts the vault in the sealed format and atomically replaces
+// the file with it. It returns ErrConflict if the file was changed since
+// it was loaded.
+// The key derivation parameters of the loaded file are kept, with a
+// fresh salt; new and legacy files use cipher.DefaultKDF. A vault shared
+// with recipients is sealed with its data key for each of them instead.
+// The current head of the audit log is sealed along, so the change must
+// be recorded in the log after it.
 func (v *Vault) Save() error {
-	f, err := os.OpenFile(v.filepath, os.O_RDWR|os.O_CREATE, 0755)
-	if err != nil {
+	// the vault in memory no longer matches the store until written
+	v.loaded = false
+	if err := v.anchorAudit(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := v.writeKeyValues(&buf); err != nil {
 		return err
 	}
-	defer f.Close()
-	w, err := cipher.EncryptWriter(v.encodingKey, f)
+	if v.shared() {
+		data, err := cipher.SealFor(v.recipientKeys(), v.dataKey, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		return v.write(data)
+	}
+	params := v.kdf
+	if params.Name == "" {
+		params = cipher.DefaultKDF
+	}
+	data, err := cipher.SealWith(v.encodingKey, params, buf.Bytes())
 	if err != nil {
 		return err
 	}
-	return v.writeKeyValues(w)
+	return v.write(data)
+}
+
+func (v *Vault) write(data []byte) error {
+	if err := v.blobs().Write(v.name, data, v.version); err != nil {
+		return err
+	}
+	v.version, v.loaded = blobVersion(data), true
+	return nil
 }
 
 func (v *Vault) writeKeyValues(w io.Writer) error {
 	enc := json.NewEncoder(w)
-	return enc.Encode(v.keyValues)
+	return enc.Encode(document{Retention: v.retention, Tokens: v.tokens, Recipients: v.recipients, Audit: v.auditAnchor, Secrets: v.keyValues})
 }
 
-// Get will give the value of given key from the secret
+// ErrNoValue is returned when the vault has no secret for a key.
+var ErrNoValue = errors.New("secret: no value for that key")
+
+// Get will give the value of given key from the secret. Every read and
+// change of the vault is recorded in its audit log once it has been
+// loaded, so attempts with a wrong key aren't recorded.
 func (v *Vault) Get(key string) (string, error) {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	err := v.Load()
+	e, err := v.GetEntry(key)
 	if err != nil {
 		return "", err
 	}
-	value, ok := v.keyValues[key]
+	return e.Value, nil
+}
+
+// GetEntry gives the value of key along with its metadata.
+func (v *Vault) GetEntry(key string) (Entry, error) {
+	unlock, err := v.lock(false)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return Entry{}, err
+	}
+	e, ok := v.keyValues[key]
 	if !ok {
-		return "", errors.New("secret: no value for that key")
+		return Entry{}, v.record("get", key, ErrNoValue)
+	}
+	if err := v.record("get", key, nil); err != nil {
+		return Entry{}, err
 	}
-	return value, nil
+	return *e, nil
 }
 
-// Set will add key value pair in the secret
-func (v *Vault) Set(key, value string) error {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	err := v.Load()
-	fmt.Println(err)
+// Entries gives every secret in the vault along with its metadata. It is
+// recorded as a single read-all in the audit log.
+func (v *Vault) Entries() (map[string]Entry, error) {
+	unlock, err := v.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := v.record("read-all", "", nil); err != nil {
+		return nil, err
+	}
+	entries := make(map[string]Entry, len(v.keyValues))
+	for key, e := range v.keyValues {
+		entries[key] = *e
+	}
+	return entries, nil
+}
+
+// Set will add key value pair in the secret. The metadata of an existing
+// key is kept unless changed by opts, and its previous value is kept in
+// the history of the key.
+func (v *Vault) Set(key, value string, opts ...SetOption) error {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return err
+	}
+	t := now()
+	e, ok := v.keyValues[key]
+	if !ok {
+		e = &Entry{Created: t}
+		v.keyValues[key] = e
+	}
+	e.update(value, t, v.retentionCount())
+	for _, opt := range opts {
+		opt(e)
+	}
+	return v.record("set", key, v.Save())
+}
+
+// ErrNoVersion is returned by Rollback for a version that isn't retained.
+var ErrNoVersion = errors.New("secret: no such version for that key")
+
+// Rollback makes a previous version of key its current value again. The
+// value it replaces is kept in the history like any other update.
+func (v *Vault) Rollback(key string, version int) error {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return err
+	}
+	e, ok := v.keyValues[key]
+	if !ok {
+		return v.record("rollback", key, ErrNoValue)
+	}
+	for _, old := range e.History {
+		if old.Version == version {
+			e.update(old.Value, now(), v.retentionCount())
+			return v.record("rollback", key, v.Save())
+		}
+	}
+	return v.record("rollback", key, ErrNoVersion)
+}
+
+// Retention gives how many previous versions the vault keeps per key.
+func (v *Vault) Retention() (int, error) {
+	unlock, err := v.lock(false)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return 0, err
+	}
+	return v.retentionCount(), nil
+}
+
+// SetRetention sets how many previous versions the vault keeps per key,
+// dropping older versions right away.
+func (v *Vault) SetRetention(n int) error {
+	if n < 0 {
+		return errors.New("secret: retention can't be negative")
+	}
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
 	if err != nil {
 		return err
 	}
-	v.keyValues[key] = value
-	err = v.Save()
-	return err
+	v.retention = &n
+	for _, e := range v.keyValues {
+		e.trim(n)
+	}
+	return v.record("retention", "", v.Save())
+}
+
+func (v *Vault) retentionCount() int {
+	if v.retention == nil {
+		return DefaultRetention
+	}
+	return *v.retention
 }
 
 // Remove will remove given key from secret
 func (v *Vault) Remove(key string) error {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	err := v.Load()
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
 	if err != nil {
 		return err
 	}
 	if _, ok := v.keyValues[key]; ok {
 		delete(v.keyValues, key)
 	} else {
-		return errors.New("secret: no value for that key")
+		return v.record("remove", key, ErrNoValue)
+	}
+	return v.record("remove", key, v.Save())
+}
+
+// Rekey re-encrypts every entry and the audit log under newKey, deriving
+// the encryption key with params. An empty newKey keeps the current
+// encoding key. A vault shared with recipients is sealed with newKey
+// again, dropping its recipients, so it needs one. Rekey fails with
+// ErrAuditTampered, leaving the vault as it was, when the audit log has
+// been tampered with.
+func (v *Vault) Rekey(newKey string, params cipher.KDFParams) error {
+	unlock, err := v.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = v.Load()
+	if err != nil {
+		return err
 	}
-	err = v.Save()
-	return err
+	if v.shared() && newKey == "" {
+		return errors.New("secret: a vault shared with recipients needs a new encoding key to rekey")
+	}
+	return v.reseal("rekey", "", func() error {
+		oldKey, oldKDF, oldRecipients := v.encodingKey, v.kdf, v.recipients
+		if newKey != "" {
+			v.encodingKey = newKey
+		}
+		v.kdf = params
+		v.recipients = make(map[string]string)
+		if err := v.Save(); err != nil {
+			v.encodingKey, v.kdf, v.recipients = oldKey, oldKDF, oldRecipients
+			return err
+		}
+		v.dataKey = nil
+		return nil
+	})
 }
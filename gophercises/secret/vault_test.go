@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,6 +46,24 @@ func getFilepath() string {
 	return path
 }
 
+func init() {
+	Cipher.DefaultKDF = Cipher.KDFParams{Name: "scrypt", N: 1 << 10, R: 8, P: 1}
+}
+
+// writeLegacyVault writes plaintext to the vault file in the old CFB format.
+func writeLegacyVault(t *testing.T, v *Vault, plaintext string) {
+	f, err := os.Create(v.filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := Cipher.EncryptWriter(v.encodingKey, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(w, plaintext)
+}
+
 func InitFile() *Vault {
 	return &Vault{
 		encodingKey: "testencodingKey",
@@ -87,10 +106,7 @@ func TestGet(t *testing.T) {
 
 	t.Run("it returns error if unable to load file", func(t *testing.T) {
 		v := InitFile()
-		er := v.Set("test_key", "testkeyvalue")
-		if er != nil {
-			log.Fatal(er)
-		}
+		writeLegacyVault(t, v, `{"test_key":"testkeyvalue"}`)
 
 		f := &fakeCipher{err: errors.New("decrypt: unable to read the full iv"), number: 2}
 		Cipher.IoRead = f.ioread
@@ -245,8 +261,33 @@ func TestSave(t *testing.T) {
 			filepath:    "",
 		}
 		err := v.Save()
-		expected := "open : no such file or directory"
-		assert.Equal(t, expected, err.Error())
+		assert.Contains(t, err.Error(), "no such file or directory")
+	})
+
+	t.Run("it writes the file with owner only permissions", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Save())
+		info, err := os.Stat(v.filepath)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("it keeps the previous generation when backup is set", func(t *testing.T) {
+		v := InitFile()
+		defer removeVault(v)
+		defer os.Remove(v.filepath + ".bak")
+		v.SetBackup(true)
+		assert.Nil(t, v.Set("test_key", "first"))
+		assert.Nil(t, v.Set("test_key", "second"))
+		value, _ := v.Get("test_key")
+		assert.Equal(t, "second", value)
+
+		// restoring the backup keeps the audit log valid
+		assert.Nil(t, os.Rename(v.filepath+".bak", v.filepath))
+		value, err := File(v.encodingKey, v.filepath).Get("test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "first", value)
 	})
 }
 
@@ -287,9 +328,115 @@ func TestLoad(t *testing.T) {
 		assert.Equal(t, s.err, err)
 	})
 
+	defer func() {
+		Cipher.IoRead = io.ReadFull
+		Cipher.NewDecryptStream = Cipher.DecryptStream
+	}()
 }
 
 func TestFile(t *testing.T) {
 	v := File("testkey", "/tmp/test")
 	assert.Equal(t, v.encodingKey, "testkey")
 }
+
+func TestSealedFormat(t *testing.T) {
+	t.Run("it reads a legacy vault and upgrades it on save", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		writeLegacyVault(t, v, `{"old_key":"old_value"}`)
+
+		value, err := v.Get("old_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "old_value", value)
+
+		assert.Nil(t, v.Set("new_key", "new_value"))
+		data, _ := ioutil.ReadFile(v.filepath)
+		assert.True(t, Cipher.IsSealed(data))
+
+		value, err = v.Get("old_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "old_value", value)
+	})
+
+	t.Run("it reports a wrong key explicitly", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", "testkeyvalue"))
+
+		wrong := File("wrongencodingKey", v.filepath)
+		_, err := wrong.Get("test_key")
+		assert.Equal(t, Cipher.ErrAuthentication, err)
+	})
+
+	t.Run("it reports a corrupted file explicitly", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", "testkeyvalue"))
+		data, _ := ioutil.ReadFile(v.filepath)
+		data[len(data)-1] ^= 0xff
+		ioutil.WriteFile(v.filepath, data, 0600)
+
+		_, err := v.Get("test_key")
+		assert.Equal(t, Cipher.ErrAuthentication, err)
+	})
+
+	t.Run("it truncates a longer previous file", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", strings.Repeat("x", 1024)))
+		assert.Nil(t, v.Set("test_key", "short"))
+
+		value, err := v.Get("test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "short", value)
+	})
+}
+
+func TestRekey(t *testing.T) {
+	pbkdf2 := Cipher.KDFParams{Name: "pbkdf2", Iterations: 1000}
+
+	t.Run("it re-derives with new params and keeps entries", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", "testkeyvalue"))
+
+		assert.Nil(t, v.Rekey("", pbkdf2))
+		data, _ := ioutil.ReadFile(v.filepath)
+		h, _ := Cipher.ReadHeader(data)
+		assert.Equal(t, "pbkdf2", h.KDF.Name)
+
+		assert.Nil(t, v.Set("other_key", "othervalue"))
+		data, _ = ioutil.ReadFile(v.filepath)
+		h, _ = Cipher.ReadHeader(data)
+		assert.Equal(t, "pbkdf2", h.KDF.Name)
+
+		value, err := File("testencodingKey", v.filepath).Get("test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "testkeyvalue", value)
+	})
+
+	t.Run("it changes the passphrase", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", "testkeyvalue"))
+
+		assert.Nil(t, v.Rekey("newencodingKey", pbkdf2))
+		_, err := File("testencodingKey", v.filepath).Get("test_key")
+		assert.Equal(t, Cipher.ErrAuthentication, err)
+		value, err := File("newencodingKey", v.filepath).Get("test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "testkeyvalue", value)
+	})
+
+	t.Run("it leaves the vault untouched for invalid params", func(t *testing.T) {
+		v := InitFile()
+		defer os.Remove(v.filepath)
+		assert.Nil(t, v.Set("test_key", "testkeyvalue"))
+
+		err := v.Rekey("newencodingKey", Cipher.KDFParams{Name: "pbkdf2"})
+		assert.NotNil(t, err)
+		value, err := File("testencodingKey", v.filepath).Get("test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, "testkeyvalue", value)
+	})
+}
@@ -3,26 +3,103 @@ package cmd
 import (
 	"fmt"
 	"gophercises/task/db"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	addDue      string
+	addPriority string
+	addTags     []string
+	addNotes    string
+	addEvery    string
+)
+
 // addCmd represents the add command
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a new task in CLI manager",
 	Run: func(cmd *cobra.Command, args []string) {
-		task := strings.Join(args, " ")
-		_, err := db.NewCreateTask(task)
+		task := db.Task{
+			Value: strings.Join(args, " "),
+			Tags:  addTags,
+			Notes: addNotes,
+		}
+		var err error
+		if task.Priority, err = db.ParsePriority(addPriority); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if addDue != "" {
+			due, err := parseDue(addDue, time.Now())
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			task.Due = &due
+		}
+		if addEvery != "" {
+			r, err := db.ParseRecurrence(addEvery)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			task.Every = r.String()
+			// the first occurrence of a recurring task is due today
+			if task.Due == nil {
+				today, _ := parseDue("today", time.Now())
+				task.Due = &today
+			}
+		}
+		_, err = db.NewCreateTask(task)
 		if err != nil {
 			fmt.Println("Something went wrong:", err)
 			return
 		}
-		fmt.Printf("Added \"%s\" to your task list.\n", task)
+		fmt.Printf("Added \"%s\" to your task list.\n", task.Value)
 	},
 }
 
+// parseDue accepts "today", "tomorrow", a weekday such as "friday" for
+// the next one, a number of days such as "3d", a date such as
+// "2025-12-31" or an RFC 3339 time. Days start at local midnight.
+func parseDue(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	for d := 1; d <= 7; d++ {
+		day := today.AddDate(0, 0, d)
+		if s == strings.ToLower(day.Weekday().String()) {
+			return day, nil
+		}
+	}
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return today.AddDate(0, 0, n), nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(s)); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid due date %q, use today, tomorrow, a weekday, a number of days such as 3d or a date (2006-01-02)", s)
+}
+
 func init() {
+	addCmd.Flags().StringVar(&addDue, "due", "", "when the task is due: today, tomorrow, a weekday, 3d or 2006-01-02")
+	addCmd.Flags().StringVarP(&addPriority, "priority", "p", "", "low, medium or high")
+	addCmd.Flags().StringSliceVarP(&addTags, "tag", "t", nil, "tags for the task")
+	addCmd.Flags().StringVarP(&addNotes, "notes", "n", "", "notes about the task")
+	addCmd.Flags().StringVar(&addEvery, "every", "", "repeat the task every day, week or month, or on weekdays such as mon,thu")
 	RootCmd.AddCommand(addCmd)
 }
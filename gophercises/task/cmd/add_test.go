@@ -6,9 +6,11 @@ import (
 	"gophercises/task/db"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
 )
 
 type fakeTask struct {
@@ -16,7 +18,7 @@ type fakeTask struct {
 	tasks []db.Task
 }
 
-func (f *fakeTask) createTask(s string) (int, error) {
+func (f *fakeTask) createTask(task db.Task) (int, error) {
 	return 0, f.err
 }
 
@@ -41,3 +43,74 @@ func TestAdd(t *testing.T) {
 		db.NewCreateTask = db.CreateTask
 	}()
 }
+
+func TestAddMetadata(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		addDue, addPriority, addTags, addNotes = "", "", nil, ""
+	}()
+
+	t.Run("it stores priority, due date, tags and notes", func(t *testing.T) {
+		addDue, addPriority, addTags, addNotes = "2030-01-02", "high", []string{"ops"}, "see runbook"
+		addCmd.Run(myCmd, []string{"rotate", "certs"})
+		tasks, _ := db.AllTasks()
+		task := tasks[len(tasks)-1]
+		assert.Equal(t, "rotate certs", task.Value)
+		assert.Equal(t, db.PriorityHigh, task.Priority)
+		assert.Equal(t, 2030, task.Due.Year())
+		assert.Equal(t, []string{"ops"}, task.Tags)
+		assert.Equal(t, "see runbook", task.Notes)
+	})
+
+	t.Run("it rejects an invalid priority or due date", func(t *testing.T) {
+		before, _ := db.AllTasks()
+		addDue, addPriority = "", "urgent"
+		addCmd.Run(myCmd, []string{"nope"})
+		addDue, addPriority = "someday", ""
+		addCmd.Run(myCmd, []string{"nope"})
+		after, _ := db.AllTasks()
+		assert.Equal(t, len(before), len(after))
+	})
+}
+
+func TestParseDue(t *testing.T) {
+	// a Thursday
+	now := time.Date(2020, 1, 2, 15, 4, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	for s, want := range map[string]time.Time{
+		"today":      day(2),
+		"Tomorrow":   day(3),
+		"monday":     day(6),
+		"thursday":   day(9),
+		"3d":         day(5),
+		"2020-01-20": day(20),
+	} {
+		got, err := parseDue(s, now)
+		assert.Nil(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+	_, err := parseDue("someday", now)
+	assert.NotNil(t, err)
+}
+
+func TestAddEvery(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { addDue, addEvery = "", "" }()
+
+	t.Run("it stores the rule and makes the task due today", func(t *testing.T) {
+		addEvery = "Weekly"
+		addCmd.Run(myCmd, []string{"review", "backups"})
+		tasks, _ := db.AllTasks()
+		task := tasks[len(tasks)-1]
+		assert.Equal(t, "week", task.Every)
+		assert.Equal(t, time.Now().Day(), task.Due.Day())
+	})
+
+	t.Run("it rejects an invalid rule", func(t *testing.T) {
+		before, _ := db.AllTasks()
+		addEvery = "fortnight"
+		addCmd.Run(myCmd, []string{"nope"})
+		after, _ := db.AllTasks()
+		assert.Equal(t, len(before), len(after))
+	})
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var completedSince string
+
+// completedCmd represents the completed command
+var completedCmd = &cobra.Command{
+	Use:   "completed",
+	Short: "Lists completed tasks.",
+	Long: `Lists completed tasks, most recently completed last, along with the IDs
+"task undo" takes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		var since time.Time
+		if completedSince != "" {
+			d, err := parseSince(completedSince)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			since = now.Add(-d)
+		}
+		tasks, err := db.NewCompletedTasks()
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		var shown int
+		for _, task := range tasks {
+			if task.Completed == nil || task.Completed.Before(since) {
+				continue
+			}
+			if shown == 0 {
+				fmt.Println("You have completed the following tasks:")
+			}
+			shown++
+			fmt.Printf("%d. %s (completed %s)\n", task.Key, task.Value, task.Completed.Local().Format("2006-01-02 15:04"))
+		}
+		if shown == 0 {
+			fmt.Println("You have not completed any tasks yet!")
+		}
+	},
+}
+
+// parseSince accepts a duration such as "24h" or a number of days such
+// as "7d".
+func parseSince(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid duration %q, use for example 24h or 7d", s)
+}
+
+func init() {
+	completedCmd.Flags().StringVar(&completedSince, "since", "", "only tasks completed this long ago or less, such as 24h or 7d")
+	RootCmd.AddCommand(completedCmd)
+}
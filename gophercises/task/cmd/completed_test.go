@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"gophercises/task/db"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeTask) reopenTask(k int) error {
+	return f.err
+}
+
+func TestCompleted(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		completedSince = ""
+		db.NewCompletedTasks = db.CompletedTasks
+		db.NewReopenTask = db.ReopenTask
+	}()
+
+	t.Run("it lists and reopens completed tasks", func(t *testing.T) {
+		addCmd.Run(myCmd, []string{"finish", "report"})
+		tasks, _ := db.AllTasks()
+		doCmd.Run(myCmd, []string{strconv.Itoa(tasks[len(tasks)-1].Key)})
+		completedSince = "1h"
+		completedCmd.Run(myCmd, nil)
+
+		done, _ := db.CompletedTasks()
+		assert.Equal(t, "finish report", done[len(done)-1].Value)
+		undoCmd.Run(myCmd, []string{strconv.Itoa(done[len(done)-1].Key)})
+		after, _ := db.AllTasks()
+		assert.Equal(t, len(tasks), len(after))
+		assert.Equal(t, "finish report", after[len(after)-1].Value)
+	})
+
+	t.Run("it rejects an invalid since", func(t *testing.T) {
+		completedSince = "yesterday"
+		completedCmd.Run(myCmd, nil)
+	})
+
+	t.Run("it fails if completed tasks are having error", func(t *testing.T) {
+		completedSince = ""
+		f := &fakeTask{err: errors.New("Failed")}
+		db.NewCompletedTasks = f.allTask
+		completedCmd.Run(myCmd, nil)
+		undoCmd.Run(myCmd, []string{"1"})
+	})
+
+	t.Run("it fails to reopen if error occurs", func(t *testing.T) {
+		f := &fakeTask{tasks: []db.Task{{Key: 1, Value: "x"}}, err: nil}
+		db.NewCompletedTasks = f.allTask
+		db.NewReopenTask = (&fakeTask{err: errors.New("Failed")}).reopenTask
+		undoCmd.Run(myCmd, []string{"1", "5", "x"})
+	})
+}
+
+func TestParseSince(t *testing.T) {
+	d, err := parseSince("7d")
+	assert.Nil(t, err)
+	assert.Equal(t, 7*24*time.Hour, d)
+	d, err = parseSince("90m")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+	_, err = parseSince("soon")
+	assert.NotNil(t, err)
+}
@@ -17,46 +17,43 @@ package cmd
 import (
 	"fmt"
 	"gophercises/task/db"
-	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var doIndex bool
+
 // doCmd represents the do command
 var doCmd = &cobra.Command{
 	Use:   "do",
 	Short: "Marks task as complete",
+	Long: `Marks tasks as complete by the IDs shown in "task list", or by their
+position in the list with --index. Completing a recurring task adds its
+next occurrence.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var ids []int
-		for _, arg := range args {
-			id, err := strconv.Atoi(arg)
-			if err != nil {
-				fmt.Println("Failed to parse the argument:", arg)
-			} else {
-				ids = append(ids, id)
-			}
-		}
 		tasks, err := db.NewAllTasks()
 		if err != nil {
 			fmt.Println("Something went wrong:", err)
 			return
 		}
-		for _, id := range ids {
-			if id <= 0 || id > len(tasks) {
-				fmt.Println("Invalid task number:", id)
-				continue
-			}
-			task := tasks[id-1]
-			err := db.NewDeleteTask(task.Key)
+		for _, task := range resolveTasks(args, tasks, doIndex) {
+			err := db.NewCompleteTask(task.Key)
 			if err != nil {
-				fmt.Printf("Failed to mark \"%d\" as completed. Error: %s\n", id, err)
+				fmt.Printf("Failed to mark \"%d\" as completed. Error: %s\n", task.Key, err)
 			} else {
-				fmt.Printf("Marked \"%d\" as completed.\n", id)
+				fmt.Printf("Marked \"%d\" as completed.\n", task.Key)
+				if task.Every != "" {
+					if next, err := task.NextDue(time.Now()); err == nil {
+						fmt.Printf("\"%s\" is next due on %s.\n", task.Value, next.Format("2006-01-02"))
+					}
+				}
 			}
 		}
 	},
 }
 
 func init() {
+	doCmd.Flags().BoolVar(&doIndex, "index", false, "take positions in the list instead of task IDs")
 	RootCmd.AddCommand(doCmd)
 }
@@ -3,15 +3,21 @@ package cmd
 import (
 	"errors"
 	"gophercises/task/db"
+	"strconv"
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
 )
 
 func (f *fakeTask) deleteTask(k int) error {
 	return f.err
 }
 
+func (f *fakeTask) completeTask(k int) error {
+	return f.err
+}
+
 func (f *fakeTask) allTask() ([]db.Task, error) {
 	return f.tasks, f.err
 }
@@ -26,7 +32,7 @@ func TestDo(t *testing.T) {
 
 	t.Run("it fails to create task if error occurs", func(t *testing.T) {
 		f := &fakeTask{err: errors.New("Failed")}
-		db.NewDeleteTask = f.deleteTask
+		db.NewCompleteTask = f.completeTask
 		doCmd.Run(myCmd, []string{"1"})
 	})
 
@@ -47,5 +53,43 @@ func TestDo(t *testing.T) {
 	defer func() {
 		db.NewCreateTask = db.CreateTask
 		db.NewAllTasks = db.AllTasks
+		db.NewCompleteTask = db.CompleteTask
 	}()
 }
+
+func TestResolveTasks(t *testing.T) {
+	tasks := []db.Task{{Key: 4, Value: "a"}, {Key: 9, Value: "b"}}
+
+	t.Run("it finds tasks by ID", func(t *testing.T) {
+		found := resolveTasks([]string{"9", "1", "x", "4"}, tasks, false)
+		assert.Equal(t, []db.Task{tasks[1], tasks[0]}, found)
+	})
+
+	t.Run("it finds tasks by position with --index", func(t *testing.T) {
+		found := resolveTasks([]string{"1", "9"}, tasks, true)
+		assert.Equal(t, []db.Task{tasks[0]}, found)
+	})
+}
+
+func TestDoByID(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { doIndex = false }()
+	addCmd.Run(myCmd, []string{"first"})
+	addCmd.Run(myCmd, []string{"second"})
+	tasks, _ := db.AllTasks()
+	first, second := tasks[len(tasks)-2], tasks[len(tasks)-1]
+
+	t.Run("it completes the task with the given ID", func(t *testing.T) {
+		doCmd.Run(myCmd, []string{strconv.Itoa(second.Key)})
+		after, _ := db.AllTasks()
+		assert.Equal(t, first, after[len(after)-1])
+	})
+
+	t.Run("it completes by position with --index", func(t *testing.T) {
+		doIndex = true
+		doCmd.Run(myCmd, []string{strconv.Itoa(len(tasks) - 1)})
+		after, _ := db.AllTasks()
+		_, ok := findTask(after, first.Key)
+		assert.False(t, ok)
+	})
+}
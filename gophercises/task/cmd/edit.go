@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	editIndex    bool
+	editDue      string
+	editPriority string
+	editTags     []string
+	editUntags   []string
+	editNotes    string
+	editEvery    string
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit <id> [new text]",
+	Short: "Edits a task",
+	Long: `Changes the text of a task and the fields given by flags, keeping the
+others. Use "none" to clear the due date, priority, notes or recurrence.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		change, err := editChange(strings.Join(args[1:], " "), time.Now())
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		task, ok := resolveTask(args[0], editIndex)
+		if !ok {
+			return
+		}
+		err = db.NewUpdateTask(task.Key, change)
+		if err != nil {
+			fmt.Printf("Failed to edit \"%d\". Error: %s\n", task.Key, err)
+			return
+		}
+		fmt.Printf("Updated \"%d\".\n", task.Key)
+	},
+}
+
+// editChange checks the edit flags and returns the change they make to
+// a task.
+func editChange(text string, now time.Time) (func(*db.Task) error, error) {
+	var due *time.Time
+	if editDue != "" && editDue != "none" {
+		d, err := parseDue(editDue, now)
+		if err != nil {
+			return nil, err
+		}
+		due = &d
+	}
+	priority := db.PriorityNone
+	if editPriority != "" && editPriority != "none" {
+		p, err := db.ParsePriority(editPriority)
+		if err != nil {
+			return nil, err
+		}
+		priority = p
+	}
+	every := ""
+	if editEvery != "" && editEvery != "none" {
+		r, err := db.ParseRecurrence(editEvery)
+		if err != nil {
+			return nil, err
+		}
+		every = r.String()
+	}
+	return func(t *db.Task) error {
+		if text != "" {
+			t.Value = text
+		}
+		if editDue != "" {
+			t.Due, t.MonthDay = due, 0
+		}
+		if editPriority != "" {
+			t.Priority = priority
+		}
+		for _, tag := range editTags {
+			if !t.HasTag(tag) {
+				t.Tags = append(t.Tags, tag)
+			}
+		}
+		for _, tag := range editUntags {
+			var kept []string
+			for _, have := range t.Tags {
+				if !strings.EqualFold(have, tag) {
+					kept = append(kept, have)
+				}
+			}
+			t.Tags = kept
+		}
+		if editEvery != "" {
+			t.Every, t.MonthDay = every, 0
+		}
+		switch editNotes {
+		case "":
+		case "none":
+			t.Notes = ""
+		default:
+			t.Notes = editNotes
+		}
+		return nil
+	}, nil
+}
+
+func init() {
+	editCmd.Flags().BoolVar(&editIndex, "index", false, "take a position in the list instead of a task ID")
+	editCmd.Flags().StringVar(&editDue, "due", "", "when the task is due: today, tomorrow, a weekday, 3d, 2006-01-02 or none")
+	editCmd.Flags().StringVarP(&editPriority, "priority", "p", "", "low, medium, high or none")
+	editCmd.Flags().StringSliceVarP(&editTags, "tag", "t", nil, "tags to add")
+	editCmd.Flags().StringSliceVar(&editUntags, "untag", nil, "tags to remove")
+	editCmd.Flags().StringVarP(&editNotes, "notes", "n", "", "notes about the task, replacing any, or none")
+	editCmd.Flags().StringVar(&editEvery, "every", "", "repeat the task every day, week or month, on weekdays such as mon,thu, or none")
+	RootCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"gophercises/task/db"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeTask) updateTask(k int, change func(*db.Task) error) error {
+	return f.err
+}
+
+func TestEdit(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		editDue, editPriority, editTags, editUntags, editNotes = "", "", nil, nil, ""
+		db.NewUpdateTask = db.UpdateTask
+	}()
+	addTags = []string{"ops", "home"}
+	addCmd.Run(myCmd, []string{"draft"})
+	addTags = nil
+	tasks, _ := db.AllTasks()
+	id := strconv.Itoa(tasks[len(tasks)-1].Key)
+
+	t.Run("it changes the text and given fields only", func(t *testing.T) {
+		editPriority, editTags, editUntags, editNotes = "high", []string{"work"}, []string{"home"}, "details"
+		editCmd.Run(myCmd, []string{id, "final", "text"})
+		tasks, _ := db.AllTasks()
+		task := tasks[len(tasks)-1]
+		assert.Equal(t, "final text", task.Value)
+		assert.Equal(t, db.PriorityHigh, task.Priority)
+		assert.Equal(t, []string{"ops", "work"}, task.Tags)
+		assert.Equal(t, "details", task.Notes)
+	})
+
+	t.Run("it clears fields with none", func(t *testing.T) {
+		editPriority, editTags, editUntags, editNotes = "none", nil, nil, "none"
+		editCmd.Run(myCmd, []string{id})
+		tasks, _ := db.AllTasks()
+		task := tasks[len(tasks)-1]
+		assert.Equal(t, "final text", task.Value)
+		assert.Equal(t, db.PriorityNone, task.Priority)
+		assert.Empty(t, task.Notes)
+	})
+
+	t.Run("it rejects invalid flags", func(t *testing.T) {
+		editPriority, editNotes = "urgent", ""
+		editCmd.Run(myCmd, []string{id})
+		editPriority, editDue = "", "someday"
+		editCmd.Run(myCmd, []string{id})
+		editDue = ""
+	})
+
+	t.Run("it fails if error occurs", func(t *testing.T) {
+		db.NewUpdateTask = (&fakeTask{err: errors.New("Failed")}).updateTask
+		editCmd.Run(myCmd, []string{id, "x"})
+		editCmd.Run(myCmd, []string{"100000"})
+	})
+}
+
+func TestEditChange(t *testing.T) {
+	defer func() { editDue = "" }()
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	due := now
+	task := db.Task{Value: "x", Due: &due}
+
+	editDue = "tomorrow"
+	change, err := editChange("", now)
+	assert.Nil(t, err)
+	change(&task)
+	assert.Equal(t, 3, task.Due.Day())
+
+	editDue = "none"
+	change, _ = editChange("", now)
+	change(&task)
+	assert.Nil(t, task.Due)
+	assert.Equal(t, "x", task.Value)
+}
+
+func TestEditEvery(t *testing.T) {
+	defer func() { editEvery = "" }()
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	task := db.Task{Value: "x"}
+
+	editEvery = "mon,thu"
+	change, err := editChange("", now)
+	assert.Nil(t, err)
+	change(&task)
+	assert.Equal(t, "mon,thu", task.Every)
+
+	editEvery = "none"
+	change, _ = editChange("", now)
+	change(&task)
+	assert.Equal(t, "", task.Every)
+
+	editEvery = "fortnight"
+	_, err = editChange("", now)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"gophercises/task/db"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+	exportAll    bool
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports tasks as JSON, CSV or todo.txt",
+	Long: `Exports the open and completed tasks of the project, or of every project
+with --all, to stdout, or to a file with -o, for backing them up or
+moving them with "task import". Every task keeps its ID and the name of
+its project. JSON and CSV keep every field; todo.txt keeps dates to the
+day.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := db.NewExportTasks(exportAll)
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		var buf bytes.Buffer
+		if err := db.WriteTasks(&buf, exportFormat, tasks); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if exportOutput == "" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := ioutil.WriteFile(exportOutput, buf.Bytes(), 0600); err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		fmt.Printf("Exported %d tasks to %s.\n", len(tasks), exportOutput)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "json", "json, csv or todotxt")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "the file to write instead of stdout")
+	exportCmd.Flags().BoolVar(&exportAll, "all", false, "export the tasks of every project")
+	RootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"gophercises/task/db"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeTask) exportTasks(all bool) ([]db.Task, error) {
+	return f.tasks, f.err
+}
+
+func TestExportImport(t *testing.T) {
+	var myCmd *cobra.Command
+	dir, _ := ioutil.TempDir("", "task-export")
+	defer os.RemoveAll(dir)
+	defer func() {
+		exportFormat, exportOutput, exportAll, importFormat = "json", "", false, ""
+		db.NewExportTasks = db.ExportTasks
+		db.NewImportTasks = db.ImportTasks
+	}()
+	addCmd.Run(myCmd, []string{"exported", "task"})
+	tasks, _ := db.AllTasks()
+	done, _ := db.CompletedTasks()
+
+	for _, format := range db.Formats {
+		t.Run("it round-trips "+format, func(t *testing.T) {
+			exportFormat = format
+			exportOutput = filepath.Join(dir, "tasks."+format)
+			exportCmd.Run(myCmd, nil)
+			f := &fakeTask{}
+			db.NewImportTasks = f.importTasks
+			importFormat = format
+			importCmd.Run(myCmd, []string{exportOutput})
+			assert.Len(t, f.tasks, len(tasks)+len(done))
+			assert.Equal(t, "exported task", f.tasks[len(tasks)-1].Value)
+			assert.Equal(t, db.DefaultProject, f.tasks[0].Project)
+		})
+	}
+
+	t.Run("it takes the format from the extension", func(t *testing.T) {
+		assert.Equal(t, "todotxt", formatOf("todo.txt"))
+		assert.Equal(t, "csv", formatOf("tasks.csv"))
+		assert.Equal(t, "", formatOf("tasks"))
+	})
+
+	t.Run("it fails on errors", func(t *testing.T) {
+		exportFormat, exportOutput = "xml", ""
+		exportCmd.Run(myCmd, nil)
+		f := &fakeTask{err: errors.New("Failed")}
+		db.NewImportTasks = f.importTasks
+		importFormat = ""
+		importCmd.Run(myCmd, []string{filepath.Join(dir, "tasks.json")})
+		importCmd.Run(myCmd, []string{filepath.Join(dir, "missing.json")})
+		db.NewExportTasks = f.exportTasks
+		exportCmd.Run(myCmd, nil)
+	})
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var importFormat string
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Imports tasks exported by \"task export\"",
+	Long: `Imports tasks from a file written by "task export", or from stdin. The
+format is taken from the extension of the file (.json, .csv or .txt for
+todo.txt) unless given with --format. Tasks go back to the project they
+were exported from, or to the current project when the file has none,
+after its tasks. They keep their IDs when these are free; a task whose
+ID already holds the same task is skipped, so importing a backup twice
+adds nothing. Nothing is imported if any of the tasks can't be read.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var r io.Reader = os.Stdin
+		format := importFormat
+		if len(args) == 1 && args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				fmt.Println("Something went wrong:", err)
+				return
+			}
+			defer f.Close()
+			r = f
+			if format == "" {
+				format = formatOf(args[0])
+			}
+		}
+		if format == "" {
+			format = "json"
+		}
+		tasks, err := db.ReadTasks(r, format)
+		if err != nil {
+			fmt.Println("Failed to read tasks:", err)
+			return
+		}
+		added, err := db.NewImportTasks(tasks)
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		if skipped := len(tasks) - added; skipped > 0 {
+			fmt.Printf("Imported %d tasks, skipped %d already there.\n", added, skipped)
+			return
+		}
+		fmt.Printf("Imported %d tasks.\n", added)
+	},
+}
+
+// formatOf gives the format of a file by its extension, or "" when it
+// has none of theirs.
+func formatOf(path string) string {
+	switch filepath.Ext(path) {
+	case ".json":
+		return "json"
+	case ".csv":
+		return "csv"
+	case ".txt":
+		return "todotxt"
+	}
+	return ""
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "json, csv or todotxt (default by the file extension, or json)")
+	RootCmd.AddCommand(importCmd)
+}
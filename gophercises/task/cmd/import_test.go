@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"gophercises/task/db"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeTask) importTasks(tasks []db.Task) (int, error) {
+	f.tasks = tasks
+	return len(tasks), f.err
+}
+
+func TestImport(t *testing.T) {
+	var myCmd *cobra.Command
+	dir, _ := ioutil.TempDir("", "task-import")
+	defer os.RemoveAll(dir)
+	defer func() {
+		importFormat = ""
+		db.UseProject(db.DefaultProject)
+	}()
+	backup := filepath.Join(dir, "backup.json")
+	ioutil.WriteFile(backup, []byte(`[
+  {"id": 900, "project": "imported", "value": "restored task", "created": "2020-01-02T00:00:00Z", "position": 1},
+  {"id": 901, "project": "imported", "value": "restored done", "created": "2020-01-02T00:00:00Z", "completed": "2020-01-03T00:00:00Z"}
+]`), 0600)
+
+	t.Run("it restores tasks to their project with their IDs", func(t *testing.T) {
+		importCmd.Run(myCmd, []string{backup})
+		db.UseProject("imported")
+		defer db.UseProject(db.DefaultProject)
+		tasks, _ := db.AllTasks()
+		if assert.Len(t, tasks, 1) {
+			assert.Equal(t, 900, tasks[0].Key)
+			assert.Equal(t, "restored task", tasks[0].Value)
+		}
+		done, _ := db.CompletedTasks()
+		if assert.Len(t, done, 1) {
+			assert.Equal(t, 901, done[0].Key)
+		}
+	})
+
+	t.Run("it doesn't duplicate tasks imported again", func(t *testing.T) {
+		importCmd.Run(myCmd, []string{backup})
+		db.UseProject("imported")
+		defer db.UseProject(db.DefaultProject)
+		tasks, _ := db.AllTasks()
+		assert.Len(t, tasks, 1)
+		done, _ := db.CompletedTasks()
+		assert.Len(t, done, 1)
+	})
+
+	t.Run("it reads the format given over the extension", func(t *testing.T) {
+		f := &fakeTask{}
+		db.NewImportTasks = f.importTasks
+		defer func() { db.NewImportTasks = db.ImportTasks }()
+		todo := filepath.Join(dir, "tasks.json")
+		ioutil.WriteFile(todo, []byte("(A) call mom project:family\n"), 0600)
+		importFormat = "todotxt"
+		importCmd.Run(myCmd, []string{todo})
+		if assert.Len(t, f.tasks, 1) {
+			assert.Equal(t, "call mom", f.tasks[0].Value)
+			assert.Equal(t, "family", f.tasks[0].Project)
+		}
+	})
+
+	t.Run("it imports nothing from a file it can't read", func(t *testing.T) {
+		f := &fakeTask{}
+		db.NewImportTasks = f.importTasks
+		defer func() { db.NewImportTasks = db.ImportTasks }()
+		broken := filepath.Join(dir, "broken.csv")
+		ioutil.WriteFile(broken, []byte("id,value\nnot a number,task\n"), 0600)
+		importFormat = ""
+		importCmd.Run(myCmd, []string{broken})
+		assert.Nil(t, f.tasks)
+	})
+}
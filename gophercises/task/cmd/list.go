@@ -3,15 +3,42 @@ package cmd
 import (
 	"fmt"
 	"gophercises/task/db"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	listTags     []string
+	listPriority string
+	listDue      string
+	listOverdue  bool
+	listSort     string
+	listUpcoming string
+)
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Lists all tasks.",
+	Long: `Lists all tasks along with their IDs, which "task do" and the other
+commands take. IDs don't change when other tasks are added or completed.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		filter, err := listFilter(now)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		var upcoming time.Duration
+		if listUpcoming != "" {
+			if upcoming, err = parseSince(listUpcoming); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
 		tasks, err := db.NewAllTasks()
 		if err != nil {
 			fmt.Println("Something went wrong:", err)
@@ -21,13 +48,140 @@ var listCmd = &cobra.Command{
 			fmt.Println("You have no tasks to complete!")
 			return
 		}
+		var shown []db.Task
+		for _, task := range tasks {
+			if filter(task) {
+				shown = append(shown, task)
+			}
+		}
+		if err := sortTasks(shown, listSort); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(shown) == 0 {
+			fmt.Println("No tasks match.")
+			return
+		}
 		fmt.Println("You have the following tasks:")
-		for i, task := range tasks {
-			fmt.Printf("%d. %s\n", i+1, task.Value)
+		for _, task := range shown {
+			fmt.Printf("%d. %s%s\n", task.Key, task.Value, describe(task))
+			if task.Notes != "" {
+				fmt.Printf("   %s\n", task.Notes)
+			}
+		}
+		if listUpcoming != "" {
+			printUpcoming(shown, now, now.Add(upcoming))
 		}
 	},
 }
 
+// listFilter returns whether a task matches the filter flags.
+func listFilter(now time.Time) (func(db.Task) bool, error) {
+	priority, err := db.ParsePriority(listPriority)
+	if err != nil {
+		return nil, err
+	}
+	var due time.Time
+	if listDue != "" {
+		if due, err = parseDue(listDue, now); err != nil {
+			return nil, err
+		}
+		// due by a day includes the whole day
+		due = due.AddDate(0, 0, 1)
+	}
+	return func(t db.Task) bool {
+		for _, tag := range listTags {
+			if !t.HasTag(tag) {
+				return false
+			}
+		}
+		if t.Priority < priority {
+			return false
+		}
+		if !due.IsZero() && (t.Due == nil || !t.Due.Before(due)) {
+			return false
+		}
+		return !listOverdue || t.Overdue(now)
+	}, nil
+}
+
+// sortTasks orders tasks by due date, soonest first, by priority, highest
+// first, or by creation, oldest first. Ties keep the list order.
+func sortTasks(tasks []db.Task, by string) error {
+	var less func(a, b db.Task) bool
+	switch by {
+	case "":
+		return nil
+	case "due":
+		less = func(a, b db.Task) bool {
+			return a.Due != nil && (b.Due == nil || a.Due.Before(*b.Due))
+		}
+	case "priority":
+		less = func(a, b db.Task) bool { return a.Priority > b.Priority }
+	case "created":
+		less = func(a, b db.Task) bool { return a.Created.Before(b.Created) }
+	default:
+		return fmt.Errorf("invalid sort %q, use due, priority or created", by)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return less(tasks[i], tasks[j]) })
+	return nil
+}
+
+// printUpcoming lists the occurrences recurring tasks will have until
+// until, soonest first. They are not stored until the task before them
+// is completed, so they have no ID.
+func printUpcoming(tasks []db.Task, now, until time.Time) {
+	type occurrence struct {
+		due  time.Time
+		task db.Task
+	}
+	var upcoming []occurrence
+	for _, task := range tasks {
+		for _, due := range task.Upcoming(now, until) {
+			upcoming = append(upcoming, occurrence{due, task})
+		}
+	}
+	if len(upcoming) == 0 {
+		return
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].due.Before(upcoming[j].due) })
+	fmt.Println("Upcoming:")
+	for _, o := range upcoming {
+		fmt.Printf("   %s %s (repeats %d)\n", o.due.Format("2006-01-02"), o.task.Value, o.task.Key)
+	}
+}
+
+// describe gives the metadata shown after a task, if it has any.
+func describe(t db.Task) string {
+	var parts []string
+	if t.Priority != db.PriorityNone {
+		parts = append(parts, t.Priority.String())
+	}
+	if t.Due != nil {
+		due := "due " + t.Due.Format("2006-01-02")
+		if t.Overdue(time.Now()) {
+			due = "overdue since " + t.Due.Format("2006-01-02")
+		}
+		parts = append(parts, due)
+	}
+	if t.Every != "" {
+		parts = append(parts, "every "+t.Every)
+	}
+	for _, tag := range t.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
 func init() {
+	listCmd.Flags().StringSliceVarP(&listTags, "tag", "t", nil, "only tasks with all of these tags")
+	listCmd.Flags().StringVarP(&listPriority, "priority", "p", "", "only tasks of at least this priority")
+	listCmd.Flags().StringVar(&listDue, "due", "", "only tasks due by this day: today, tomorrow, a weekday, 3d or 2006-01-02")
+	listCmd.Flags().BoolVar(&listOverdue, "overdue", false, "only overdue tasks")
+	listCmd.Flags().StringVarP(&listSort, "sort", "s", "", "order by due, priority or created")
+	listCmd.Flags().StringVar(&listUpcoming, "upcoming", "", "also list the occurrences recurring tasks will have this far ahead, such as 7d")
 	RootCmd.AddCommand(listCmd)
 }
@@ -4,12 +4,15 @@ import (
 	"errors"
 	"gophercises/task/db"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestList(t *testing.T) {
 	var myCmd *cobra.Command
+	defer func() { db.NewAllTasks = db.AllTasks }()
 
 	t.Run("it lists all tasks", func(t *testing.T) {
 		listCmd.Run(myCmd, []string{})
@@ -27,3 +30,83 @@ func TestList(t *testing.T) {
 		listCmd.Run(myCmd, nil)
 	})
 }
+
+func TestListFilter(t *testing.T) {
+	now := time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC)
+	yesterday := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	nextWeek := time.Date(2020, 1, 9, 0, 0, 0, 0, time.UTC)
+	tasks := []db.Task{
+		{Value: "a", Priority: db.PriorityHigh, Due: &nextWeek, Tags: []string{"ops"}},
+		{Value: "b", Priority: db.PriorityLow, Due: &yesterday},
+		{Value: "c", Tags: []string{"ops", "home"}},
+	}
+	defer func() {
+		listTags, listPriority, listDue, listOverdue = nil, "", "", false
+	}()
+	matching := func() string {
+		filter, err := listFilter(now)
+		assert.Nil(t, err)
+		var values string
+		for _, task := range tasks {
+			if filter(task) {
+				values += task.Value
+			}
+		}
+		return values
+	}
+
+	assert.Equal(t, "abc", matching())
+	listTags = []string{"ops"}
+	assert.Equal(t, "ac", matching())
+	listTags, listPriority = nil, "low"
+	assert.Equal(t, "ab", matching())
+	listPriority, listDue = "", "tomorrow"
+	assert.Equal(t, "b", matching())
+	listDue = "2020-01-09"
+	assert.Equal(t, "ab", matching())
+	listDue, listOverdue = "", true
+	assert.Equal(t, "b", matching())
+	listOverdue, listPriority = false, "urgent"
+	_, err := listFilter(now)
+	assert.NotNil(t, err)
+}
+
+func TestSortTasks(t *testing.T) {
+	early := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.AddDate(0, 0, 1)
+	tasks := []db.Task{
+		{Key: 1, Value: "a", Created: late},
+		{Key: 2, Value: "b", Due: &late, Priority: db.PriorityLow, Created: early},
+		{Key: 3, Value: "c", Due: &early, Priority: db.PriorityHigh, Created: late},
+	}
+	order := func() string {
+		var s string
+		for _, task := range tasks {
+			s += task.Value
+		}
+		return s
+	}
+	assert.Nil(t, sortTasks(tasks, "due"))
+	assert.Equal(t, "cba", order())
+	assert.Nil(t, sortTasks(tasks, "priority"))
+	assert.Equal(t, "cba", order())
+	assert.Nil(t, sortTasks(tasks, "created"))
+	assert.Equal(t, "bca", order())
+	assert.NotNil(t, sortTasks(tasks, "size"))
+}
+
+func TestListUpcoming(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		listUpcoming = ""
+		db.NewAllTasks = db.AllTasks
+	}()
+	due := time.Now()
+	f := &fakeTask{tasks: []db.Task{{Key: 1, Value: "stand-up", Every: "day", Due: &due}}}
+	db.NewAllTasks = f.allTask
+
+	listUpcoming = "7d"
+	listCmd.Run(myCmd, nil)
+	listUpcoming = "soon"
+	listCmd.Run(myCmd, nil)
+}
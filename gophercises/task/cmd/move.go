@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	moveIndex bool
+	moveTo    string
+)
+
+// moveCmd represents the move command
+var moveCmd = &cobra.Command{
+	Use:   "move <id> <position> | move <id> --to <project>",
+	Short: "Moves a task to another position in the list or another project",
+	Long: `Moves a task to a 1-based position in the list, or with --to to the end
+of another project. Its ID stays the same. A position past the end moves
+it last.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if (moveTo == "") != (len(args) == 2) {
+			fmt.Println("Give either a position or a project with --to.")
+			return
+		}
+		if moveTo != "" {
+			task, ok := resolveTask(args[0], moveIndex)
+			if !ok {
+				return
+			}
+			err := db.NewMoveToProject(task.Key, moveTo)
+			if err != nil {
+				fmt.Printf("Failed to move \"%d\". Error: %s\n", task.Key, err)
+				return
+			}
+			fmt.Printf("Moved \"%s\" to project %s.\n", task.Value, moveTo)
+			return
+		}
+		position, err := strconv.Atoi(args[1])
+		if err != nil || position < 1 {
+			fmt.Println("Invalid position:", args[1])
+			return
+		}
+		task, ok := resolveTask(args[0], moveIndex)
+		if !ok {
+			return
+		}
+		err = db.NewMoveTask(task.Key, position)
+		if err != nil {
+			fmt.Printf("Failed to move \"%d\". Error: %s\n", task.Key, err)
+			return
+		}
+		fmt.Printf("Moved \"%s\" to position %d.\n", task.Value, position)
+	},
+}
+
+func init() {
+	moveCmd.Flags().BoolVar(&moveIndex, "index", false, "take a position in the list instead of a task ID")
+	moveCmd.Flags().StringVar(&moveTo, "to", "", "the project to move the task to")
+	RootCmd.AddCommand(moveCmd)
+}
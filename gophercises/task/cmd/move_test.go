@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"gophercises/task/db"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMove(t *testing.T) {
+	var myCmd *cobra.Command
+	addCmd.Run(myCmd, []string{"move", "me", "up"})
+	tasks, _ := db.AllTasks()
+	task := tasks[len(tasks)-1]
+
+	t.Run("it moves a task keeping its ID", func(t *testing.T) {
+		moveCmd.Run(myCmd, []string{strconv.Itoa(task.Key), "1"})
+		after, _ := db.AllTasks()
+		assert.Equal(t, task.Key, after[0].Key)
+		assert.Equal(t, "move me up", after[0].Value)
+	})
+
+	t.Run("it rejects an invalid position or task", func(t *testing.T) {
+		moveCmd.Run(myCmd, []string{strconv.Itoa(task.Key), "first"})
+		moveCmd.Run(myCmd, []string{"100000", "1"})
+		after, _ := db.AllTasks()
+		assert.Equal(t, task.Key, after[0].Key)
+	})
+}
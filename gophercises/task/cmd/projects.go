@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+
+	"github.com/spf13/cobra"
+)
+
+// projectsCmd represents the projects command
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "Lists projects with their open and completed tasks",
+	Long: `Lists projects with their open and completed tasks. A project is
+created when a task is first added to it with "task --project name add".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		projects, err := db.NewProjects()
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		for _, p := range projects {
+			current := " "
+			if p.Name == db.CurrentProject() {
+				current = "*"
+			}
+			fmt.Printf("%s %s: %d open, %d done\n", current, p.Name, p.Open, p.Done)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(projectsCmd)
+}
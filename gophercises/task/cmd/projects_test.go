@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"gophercises/task/db"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func (f *fakeTask) projects() ([]db.Project, error) {
+	return nil, f.err
+}
+
+func TestProjects(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		project, moveTo = db.DefaultProject, ""
+		db.UseProject(db.DefaultProject)
+		db.NewProjects = db.Projects
+	}()
+
+	t.Run("it adds and moves tasks across projects", func(t *testing.T) {
+		project = "infra"
+		assert.Nil(t, RootCmd.PersistentPreRunE(myCmd, nil))
+		addCmd.Run(myCmd, []string{"patch", "servers"})
+		tasks, _ := db.AllTasks()
+		task := tasks[len(tasks)-1]
+		assert.Equal(t, "patch servers", task.Value)
+		projectsCmd.Run(myCmd, nil)
+
+		moveTo = "ops"
+		moveCmd.Run(myCmd, []string{strconv.Itoa(task.Key)})
+		after, _ := db.AllTasks()
+		assert.Len(t, after, len(tasks)-1)
+		db.UseProject("ops")
+		moved, _ := db.AllTasks()
+		assert.Equal(t, task.Key, moved[len(moved)-1].Key)
+	})
+
+	t.Run("it needs a position or a project to move", func(t *testing.T) {
+		moveTo = "ops"
+		moveCmd.Run(myCmd, []string{"1", "2"})
+		moveTo = ""
+		moveCmd.Run(myCmd, []string{"1"})
+	})
+
+	t.Run("it fails if projects are having error", func(t *testing.T) {
+		db.NewProjects = (&fakeTask{err: errors.New("Failed")}).projects
+		projectsCmd.Run(myCmd, nil)
+	})
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+	"strconv"
+)
+
+// resolveTasks finds the tasks args refer to, by their ID or, with
+// byIndex, by their 1-based position in tasks. Arguments that match no
+// task are reported and skipped.
+func resolveTasks(args []string, tasks []db.Task, byIndex bool) []db.Task {
+	var found []db.Task
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			fmt.Println("Failed to parse the argument:", arg)
+			continue
+		}
+		if byIndex {
+			if id <= 0 || id > len(tasks) {
+				fmt.Println("Invalid task number:", id)
+				continue
+			}
+			found = append(found, tasks[id-1])
+			continue
+		}
+		task, ok := findTask(tasks, id)
+		if !ok {
+			fmt.Println("No task with ID:", id)
+			continue
+		}
+		found = append(found, task)
+	}
+	return found
+}
+
+func findTask(tasks []db.Task, key int) (db.Task, bool) {
+	for _, task := range tasks {
+		if task.Key == key {
+			return task, true
+		}
+	}
+	return db.Task{}, false
+}
+
+// resolveTask finds the open task arg refers to, reporting why when
+// there is none.
+func resolveTask(arg string, byIndex bool) (db.Task, bool) {
+	tasks, err := db.NewAllTasks()
+	if err != nil {
+		fmt.Println("Something went wrong:", err)
+		return db.Task{}, false
+	}
+	found := resolveTasks([]string{arg}, tasks, byIndex)
+	if len(found) == 0 {
+		return db.Task{}, false
+	}
+	return found[0], true
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+
+	"github.com/spf13/cobra"
+)
+
+var rmIndex bool
+
+// rmCmd represents the rm command
+var rmCmd = &cobra.Command{
+	Use:   "rm",
+	Short: "Removes tasks without completing them",
+	Long: `Removes tasks by the IDs shown in "task list", or by their position in
+the list with --index. Removed tasks are not kept as completed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := db.NewAllTasks()
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		for _, task := range resolveTasks(args, tasks, rmIndex) {
+			err := db.NewDeleteTask(task.Key)
+			if err != nil {
+				fmt.Printf("Failed to remove \"%d\". Error: %s\n", task.Key, err)
+			} else {
+				fmt.Printf("Removed \"%s\".\n", task.Value)
+			}
+		}
+	},
+}
+
+func init() {
+	rmCmd.Flags().BoolVar(&rmIndex, "index", false, "take positions in the list instead of task IDs")
+	RootCmd.AddCommand(rmCmd)
+}
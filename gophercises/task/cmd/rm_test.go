@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+	"gophercises/task/db"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRm(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() { db.NewDeleteTask = db.DeleteTask }()
+
+	t.Run("it removes a task without completing it", func(t *testing.T) {
+		addCmd.Run(myCmd, []string{"mistake"})
+		tasks, _ := db.AllTasks()
+		task := tasks[len(tasks)-1]
+		completed, _ := db.CompletedTasks()
+		rmCmd.Run(myCmd, []string{strconv.Itoa(task.Key)})
+
+		after, _ := db.AllTasks()
+		assert.Len(t, after, len(tasks)-1)
+		_, ok := findTask(after, task.Key)
+		assert.False(t, ok)
+		stillCompleted, _ := db.CompletedTasks()
+		assert.Len(t, stillCompleted, len(completed))
+	})
+
+	t.Run("it fails if error occurs", func(t *testing.T) {
+		addCmd.Run(myCmd, []string{"keep"})
+		tasks, _ := db.AllTasks()
+		db.NewDeleteTask = (&fakeTask{err: errors.New("Failed")}).deleteTask
+		rmCmd.Run(myCmd, []string{strconv.Itoa(tasks[len(tasks)-1].Key), "x"})
+		after, _ := db.AllTasks()
+		assert.Len(t, after, len(tasks))
+	})
+}
@@ -1,9 +1,22 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"gophercises/task/db"
+
+	"github.com/spf13/cobra"
+)
+
+var project string
 
 // RootCmd root command of CLI manager
 var RootCmd = &cobra.Command{
 	Use:   "task",
 	Short: "Task is a CLI task manager",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return db.UseProject(project)
+	},
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVarP(&project, "project", "P", db.DefaultProject, "the project whose tasks to work on")
 }
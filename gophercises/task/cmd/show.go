@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var showIndex bool
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Shows every field of a task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		task, ok := resolveTask(args[0], showIndex)
+		if !ok {
+			return
+		}
+		printTask(task)
+	},
+}
+
+func printTask(task db.Task) {
+	fmt.Printf("ID:        %d\n", task.Key)
+	fmt.Printf("Task:      %s\n", task.Value)
+	if task.Priority != db.PriorityNone {
+		fmt.Printf("Priority:  %s\n", task.Priority)
+	}
+	if task.Due != nil {
+		fmt.Printf("Due:       %s\n", task.Due.Format("2006-01-02"))
+	}
+	if task.Every != "" {
+		fmt.Printf("Repeats:   every %s\n", task.Every)
+	}
+	if len(task.Tags) > 0 {
+		fmt.Printf("Tags:      %s\n", strings.Join(task.Tags, ", "))
+	}
+	if task.Notes != "" {
+		fmt.Printf("Notes:     %s\n", task.Notes)
+	}
+	if !task.Created.IsZero() {
+		fmt.Printf("Created:   %s\n", task.Created.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+func init() {
+	showCmd.Flags().BoolVar(&showIndex, "index", false, "take a position in the list instead of a task ID")
+	RootCmd.AddCommand(showCmd)
+}
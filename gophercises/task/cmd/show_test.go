@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"gophercises/task/db"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestShow(t *testing.T) {
+	var myCmd *cobra.Command
+	defer func() {
+		addDue, addPriority, addTags, addNotes = "", "", nil, ""
+		showIndex = false
+	}()
+
+	t.Run("it shows a task", func(t *testing.T) {
+		addDue, addPriority, addTags, addNotes = "tomorrow", "low", []string{"ops"}, "notes"
+		addCmd.Run(myCmd, []string{"shown"})
+		tasks, _ := db.AllTasks()
+		showCmd.Run(myCmd, []string{strconv.Itoa(tasks[len(tasks)-1].Key)})
+		showIndex = true
+		showCmd.Run(myCmd, []string{strconv.Itoa(len(tasks))})
+	})
+
+	t.Run("it fails if task is not present", func(t *testing.T) {
+		showIndex = false
+		showCmd.Run(myCmd, []string{"100000"})
+	})
+}
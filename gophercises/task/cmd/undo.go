@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"gophercises/task/db"
+
+	"github.com/spf13/cobra"
+)
+
+var undoIndex bool
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Reopens completed tasks",
+	Long: `Reopens completed tasks by the IDs shown in "task completed", or by
+their position in that list with --index.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := db.NewCompletedTasks()
+		if err != nil {
+			fmt.Println("Something went wrong:", err)
+			return
+		}
+		for _, task := range resolveTasks(args, tasks, undoIndex) {
+			err := db.NewReopenTask(task.Key)
+			if err != nil {
+				fmt.Printf("Failed to reopen \"%d\". Error: %s\n", task.Key, err)
+			} else {
+				fmt.Printf("Reopened \"%s\".\n", task.Value)
+			}
+		}
+	},
+}
+
+func init() {
+	undoCmd.Flags().BoolVar(&undoIndex, "index", false, "take positions in the list instead of task IDs")
+	RootCmd.AddCommand(undoCmd)
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/boltdb/bolt"
+)
+
+// ErrNoTask is returned for a key that isn't in the bucket looked in.
+var ErrNoTask = errors.New("no such task")
+
+// CompleteTask moves the task of given key to the completed tasks,
+// stamped with the time of completion. It keeps its key, so it can be
+// reopened in its place. Completing a recurring task adds its next
+// occurrence in its place in the list, under a new key, in the same
+// transaction.
+func CompleteTask(key int) error {
+	t := now()
+	return db.Update(func(tx *bolt.Tx) error {
+		task, err := moveTask(tx, key, taskBucket, completedBucket, func(task *Task) {
+			task.Completed = &t
+		})
+		if err != nil || task.Every == "" {
+			return err
+		}
+		due, err := task.NextDue(t)
+		if err != nil {
+			return err
+		}
+		next := task
+		next.Due, next.Created, next.Completed = &due, t, nil
+		if task.Every == "month" {
+			next.MonthDay = task.monthDay()
+		}
+		id, _ := tx.Bucket(taskBucket).NextSequence()
+		data, err := encodeTask(next)
+		if err != nil {
+			return err
+		}
+		b, err := projectBucket(tx, project, taskBucket, true)
+		if err != nil {
+			return err
+		}
+		return b.Put(itob(int(id)), data)
+	})
+}
+
+// ReopenTask moves the completed task of given key back to the tasks.
+func ReopenTask(key int) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		_, err := moveTask(tx, key, completedBucket, taskBucket, func(t *Task) {
+			t.Completed = nil
+		})
+		return err
+	})
+}
+
+// CompletedTasks returns the completed tasks, most recently completed
+// last.
+func CompletedTasks() ([]Task, error) {
+	tasks, err := readBucket(completedBucket)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Completed != nil && tasks[j].Completed != nil && tasks[i].Completed.Before(*tasks[j].Completed)
+	})
+	return tasks, nil
+}
+
+// moveTask moves the task of given key between buckets of the current
+// project, changed by change, and returns it.
+func moveTask(tx *bolt.Tx, key int, from, to []byte, change func(*Task)) (Task, error) {
+	src, err := projectBucket(tx, project, from, false)
+	if err != nil {
+		return Task{}, err
+	}
+	if src == nil || src.Get(itob(key)) == nil {
+		return Task{}, ErrNoTask
+	}
+	dst, err := projectBucket(tx, project, to, true)
+	if err != nil {
+		return Task{}, err
+	}
+	task := decodeTask(key, src.Get(itob(key)))
+	change(&task)
+	data, err := encodeTask(task)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := dst.Put(itob(key), data); err != nil {
+		return Task{}, err
+	}
+	return task, src.Delete(itob(key))
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteTask(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "completed_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+	Init(dbPath)
+	defer func() { now = time.Now }()
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return t0 }
+
+	first, _ := CreateTask(Task{Value: "first"})
+	second, _ := CreateTask(Task{Value: "second"})
+
+	t.Run("it moves completed tasks aside with a timestamp", func(t *testing.T) {
+		now = func() time.Time { return t0.Add(time.Hour) }
+		assert.Nil(t, CompleteTask(first))
+		now = func() time.Time { return t0 }
+		assert.Nil(t, CompleteTask(second))
+
+		tasks, _ := AllTasks()
+		assert.Empty(t, tasks)
+		done, err := CompletedTasks()
+		assert.Nil(t, err)
+		assert.Len(t, done, 2)
+		assert.Equal(t, "second", done[0].Value)
+		assert.Equal(t, t0, *done[0].Completed)
+		assert.Equal(t, "first", done[1].Value)
+	})
+
+	t.Run("it reopens a task under its key", func(t *testing.T) {
+		assert.Nil(t, ReopenTask(first))
+		tasks, _ := AllTasks()
+		assert.Equal(t, []Task{{Key: first, Value: "first", Created: t0, Position: 1}}, tasks)
+		done, _ := CompletedTasks()
+		assert.Len(t, done, 1)
+	})
+
+	t.Run("it fails for unknown keys", func(t *testing.T) {
+		assert.Equal(t, ErrNoTask, CompleteTask(100))
+		assert.Equal(t, ErrNoTask, ReopenTask(first))
+	})
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/boltdb/bolt"
+)
+
+// UpdateTask changes the task of given key in place with change, in a
+// single transaction. The key can't be changed.
+func UpdateTask(key int, change func(*Task) error) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := projectBucket(tx, project, taskBucket, false)
+		if err != nil {
+			return err
+		}
+		if b == nil || b.Get(itob(key)) == nil {
+			return ErrNoTask
+		}
+		data := b.Get(itob(key))
+		task := decodeTask(key, data)
+		if err := change(&task); err != nil {
+			return err
+		}
+		data, err = encodeTask(task)
+		if err != nil {
+			return err
+		}
+		return b.Put(itob(key), data)
+	})
+}
+
+// MoveTask moves the task of given key to the 1-based position in the
+// list, renumbering the positions of every task. Positions past the end
+// move it last.
+func MoveTask(key, position int) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := projectBucket(tx, project, taskBucket, false)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return ErrNoTask
+		}
+		var tasks []Task
+		err = b.ForEach(func(k, v []byte) error {
+			tasks = append(tasks, decodeTask(btoi(k), v))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Position < tasks[j].Position })
+		moved := -1
+		for i := range tasks {
+			if tasks[i].Key == key {
+				moved = i
+			}
+		}
+		if moved < 0 {
+			return ErrNoTask
+		}
+		task := tasks[moved]
+		tasks = append(tasks[:moved], tasks[moved+1:]...)
+		if position < 1 {
+			position = 1
+		}
+		if position > len(tasks)+1 {
+			position = len(tasks) + 1
+		}
+		tasks = append(tasks[:position-1], append([]Task{task}, tasks[position-1:]...)...)
+		for i, task := range tasks {
+			task.Position = i + 1
+			data, err := encodeTask(task)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(task.Key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lastPosition gives the highest position in bucket b.
+func lastPosition(b *bolt.Bucket) (int, error) {
+	last := 0
+	err := b.ForEach(func(k, v []byte) error {
+		if task := decodeTask(btoi(k), v); task.Position > last {
+			last = task.Position
+		}
+		return nil
+	})
+	return last, err
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateTask(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "edit_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+	Init(dbPath)
+	key, _ := CreateTask(Task{Value: "draft", Tags: []string{"ops"}})
+
+	t.Run("it changes a task in place", func(t *testing.T) {
+		err := UpdateTask(key, func(task *Task) error {
+			task.Value, task.Priority = "final", PriorityHigh
+			return nil
+		})
+		assert.Nil(t, err)
+		tasks, _ := AllTasks()
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, key, tasks[0].Key)
+		assert.Equal(t, "final", tasks[0].Value)
+		assert.Equal(t, PriorityHigh, tasks[0].Priority)
+		assert.Equal(t, []string{"ops"}, tasks[0].Tags)
+	})
+
+	t.Run("it keeps the task when the change fails", func(t *testing.T) {
+		failed := errors.New("Failed")
+		err := UpdateTask(key, func(task *Task) error {
+			task.Value = "lost"
+			return failed
+		})
+		assert.Equal(t, failed, err)
+		tasks, _ := AllTasks()
+		assert.Equal(t, "final", tasks[0].Value)
+	})
+
+	t.Run("it fails for unknown keys", func(t *testing.T) {
+		assert.Equal(t, ErrNoTask, UpdateTask(100, func(*Task) error { return nil }))
+	})
+}
+
+func TestMoveTask(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "move_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+	Init(dbPath)
+	for _, value := range []string{"a", "b", "c", "d"} {
+		CreateTask(Task{Value: value})
+	}
+	order := func() string {
+		tasks, _ := AllTasks()
+		var s string
+		for _, task := range tasks {
+			s += task.Value
+		}
+		return s
+	}
+
+	assert.Nil(t, MoveTask(4, 1))
+	assert.Equal(t, "dabc", order())
+	assert.Nil(t, MoveTask(4, 3))
+	assert.Equal(t, "abdc", order())
+	assert.Nil(t, MoveTask(1, 10))
+	assert.Equal(t, "bdca", order())
+	CreateTask(Task{Value: "e"})
+	assert.Equal(t, "bdcae", order())
+	assert.Equal(t, ErrNoTask, MoveTask(100, 1))
+
+	tasks, _ := AllTasks()
+	assert.Equal(t, 2, tasks[0].Key)
+}
@@ -0,0 +1,387 @@
+package db
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats are the formats tasks are exported to and imported from.
+var Formats = []string{"json", "csv", "todotxt"}
+
+// exportedTask is a task along with its key and project, which aren't
+// part of the stored record.
+type exportedTask struct {
+	ID      int    `json:"id"`
+	Project string `json:"project,omitempty"`
+	Task
+}
+
+var csvHeader = []string{"id", "project", "value", "priority", "due", "tags", "notes", "every", "month_day", "created", "position", "completed"}
+
+// WriteTasks writes tasks to w in format, one of Formats. JSON and CSV
+// keep every field; todo.txt keeps dates to the day.
+func WriteTasks(w io.Writer, format string, tasks []Task) error {
+	switch format {
+	case "json":
+		exported := make([]exportedTask, len(tasks))
+		for i, t := range tasks {
+			exported[i] = exportedTask{ID: t.Key, Project: t.Project, Task: t}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exported)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(csvHeader)
+		for _, t := range tasks {
+			cw.Write([]string{
+				strconv.Itoa(t.Key), t.Project, t.Value, t.Priority.String(), formatTime(t.Due),
+				strings.Join(t.Tags, ","), t.Notes, t.Every, formatInt(t.MonthDay), formatTime(&t.Created),
+				strconv.Itoa(t.Position), formatTime(t.Completed),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "todotxt":
+		bw := bufio.NewWriter(w)
+		for _, t := range tasks {
+			fmt.Fprintln(bw, todoLine(t))
+		}
+		return bw.Flush()
+	}
+	return unknownFormat(format)
+}
+
+// ReadTasks reads the tasks written by WriteTasks in format from r.
+func ReadTasks(r io.Reader, format string) ([]Task, error) {
+	switch format {
+	case "json":
+		var exported []exportedTask
+		if err := json.NewDecoder(r).Decode(&exported); err != nil {
+			return nil, err
+		}
+		tasks := make([]Task, len(exported))
+		for i, e := range exported {
+			tasks[i] = e.Task
+			tasks[i].Key, tasks[i].Project = e.ID, e.Project
+			var err error
+			if tasks[i].Every, err = parseEvery(e.Every); err != nil {
+				return nil, fmt.Errorf("json task %d: %v", i+1, err)
+			}
+		}
+		return tasks, nil
+	case "csv":
+		return readCSV(r)
+	case "todotxt":
+		return readTodo(r)
+	}
+	return nil, unknownFormat(format)
+}
+
+func unknownFormat(format string) error {
+	return fmt.Errorf("invalid format %q, use %s", format, strings.Join(Formats, ", "))
+}
+
+func formatInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseEvery checks the recurrence s and gives it in the form
+// ParseRecurrence reads, so an imported task can be completed.
+func parseEvery(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	r, err := ParseRecurrence(s)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// readCSV reads the columns of csvHeader by name, so they may come in
+// any order and missing ones are left empty.
+func readCSV(r io.Reader) ([]Task, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	column := make(map[string]int)
+	for i, name := range records[0] {
+		column[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := column["value"]; !ok {
+		return nil, fmt.Errorf("csv has no value column")
+	}
+	var tasks []Task
+	for n, record := range records[1:] {
+		field := func(name string) string {
+			if i, ok := column[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		t, err := csvTask(field)
+		if err != nil {
+			return nil, fmt.Errorf("csv line %d: %v", n+2, err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+func csvTask(field func(string) string) (Task, error) {
+	t := Task{Value: field("value"), Project: field("project"), Notes: field("notes")}
+	var err error
+	if t.Every, err = parseEvery(field("every")); err != nil {
+		return t, err
+	}
+	if s := field("id"); s != "" {
+		if t.Key, err = strconv.Atoi(s); err != nil {
+			return t, err
+		}
+	}
+	if s := field("position"); s != "" {
+		if t.Position, err = strconv.Atoi(s); err != nil {
+			return t, err
+		}
+	}
+	if s := field("month_day"); s != "" {
+		if t.MonthDay, err = strconv.Atoi(s); err != nil {
+			return t, err
+		}
+	}
+	if t.Priority, err = ParsePriority(field("priority")); err != nil {
+		return t, err
+	}
+	if s := field("tags"); s != "" {
+		t.Tags = strings.Split(s, ",")
+	}
+	if t.Due, err = parseTime(field("due")); err != nil {
+		return t, err
+	}
+	if t.Completed, err = parseTime(field("completed")); err != nil {
+		return t, err
+	}
+	created, err := parseTime(field("created"))
+	if created != nil {
+		t.Created = *created
+	}
+	return t, err
+}
+
+// todo.txt priorities are letters, A the most urgent.
+var todoPriorities = map[Priority]string{PriorityHigh: "A", PriorityMedium: "B", PriorityLow: "C"}
+
+func todoPriority(letter string) Priority {
+	for p, l := range todoPriorities {
+		if l == letter {
+			return p
+		}
+	}
+	return PriorityLow
+}
+
+const todoDate = "2006-01-02"
+
+// todoKeys are the keys of the key:value pairs todoLine writes.
+var todoKeys = []string{"due", "rec", "recday", "notes", "pri", "project", "id", "text"}
+
+// todoLine formats t as a todo.txt line. Tags are contexts; the due
+// date, recurrence and its day of the month, notes, project and ID are
+// key:value pairs. The project isn't a +project, which other todo.txt
+// tools use within a list. Completed tasks keep their priority as pri:,
+// as the format suggests. Text that wouldn't be read back as it is, such
+// as "email @bob", is written escaped as text:.
+func todoLine(t Task) string {
+	var parts []string
+	if t.Completed != nil {
+		parts = append(parts, "x", t.Completed.Format(todoDate))
+	} else if p, ok := todoPriorities[t.Priority]; ok {
+		parts = append(parts, "("+p+")")
+	}
+	if !t.Created.IsZero() {
+		parts = append(parts, t.Created.Format(todoDate))
+	}
+	if todoPlain(t.Value) {
+		parts = append(parts, t.Value)
+	} else {
+		parts = append(parts, "text:"+url.QueryEscape(t.Value))
+	}
+	for _, tag := range t.Tags {
+		parts = append(parts, "@"+tag)
+	}
+	if t.Due != nil {
+		parts = append(parts, "due:"+t.Due.Format(todoDate))
+	}
+	if t.Every != "" {
+		parts = append(parts, "rec:"+t.Every)
+	}
+	if t.MonthDay != 0 {
+		parts = append(parts, "recday:"+strconv.Itoa(t.MonthDay))
+	}
+	if t.Notes != "" {
+		parts = append(parts, "notes:"+url.QueryEscape(t.Notes))
+	}
+	if p, ok := todoPriorities[t.Priority]; ok && t.Completed != nil {
+		parts = append(parts, "pri:"+p)
+	}
+	if t.Project != "" {
+		parts = append(parts, "project:"+url.QueryEscape(t.Project))
+	}
+	if t.Key != 0 {
+		parts = append(parts, "id:"+strconv.Itoa(t.Key))
+	}
+	return strings.Join(parts, " ")
+}
+
+// todoPlain reports whether value can be written as the words of a
+// todo.txt line: words apart by single spaces, none of which is read as a
+// completion mark, priority, date, context or key:value pair.
+func todoPlain(value string) bool {
+	words := strings.Fields(value)
+	if len(words) == 0 || strings.Join(words, " ") != value {
+		return false
+	}
+	if first := words[0]; first == "x" || todoPriorityMark(first) {
+		return false
+	}
+	if _, err := time.Parse(todoDate, words[0]); err == nil {
+		return false
+	}
+	for _, w := range words {
+		if len(w) > 1 && w[0] == '@' {
+			return false
+		}
+		if i := strings.Index(w, ":"); i > 0 {
+			for _, key := range todoKeys {
+				if w[:i] == key {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// todoPriorityMark reports whether f is a todo.txt priority such as (A).
+func todoPriorityMark(f string) bool {
+	return len(f) == 3 && f[0] == '(' && f[2] == ')' && f[1] >= 'A' && f[1] <= 'Z'
+}
+
+// readTodo reads todo.txt lines; open tasks are positioned in the order
+// of the file. Dates are read at midnight local time.
+func readTodo(r io.Reader) ([]Task, error) {
+	var tasks []Task
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		t, err := todoTask(fields)
+		if err != nil {
+			return nil, fmt.Errorf("todo.txt line %d: %v", n, err)
+		}
+		if t.Completed == nil {
+			t.Position = len(tasks) + 1
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, s.Err()
+}
+
+func todoTask(fields []string) (Task, error) {
+	var t Task
+	date := func() *time.Time {
+		if len(fields) == 0 {
+			return nil
+		}
+		d, err := time.ParseInLocation(todoDate, fields[0], time.Local)
+		if err != nil {
+			return nil
+		}
+		fields = fields[1:]
+		return &d
+	}
+	if fields[0] == "x" {
+		fields = fields[1:]
+		t.Completed = date()
+	} else if f := fields[0]; todoPriorityMark(f) {
+		t.Priority = todoPriority(f[1:2])
+		fields = fields[1:]
+	}
+	if created := date(); created != nil {
+		t.Created = *created
+	}
+	var words []string
+	for _, f := range fields {
+		key, value := "", f
+		if i := strings.Index(f, ":"); i > 0 {
+			key, value = f[:i], f[i+1:]
+		}
+		var err error
+		switch {
+		case len(f) > 1 && f[0] == '@':
+			t.Tags = append(t.Tags, f[1:])
+
+		case key == "due":
+			d, perr := time.ParseInLocation(todoDate, value, time.Local)
+			t.Due, err = &d, perr
+		case key == "rec":
+			t.Every, err = parseEvery(value)
+		case key == "recday":
+			t.MonthDay, err = strconv.Atoi(value)
+		case key == "notes":
+			t.Notes, err = url.QueryUnescape(value)
+		case key == "pri" && len(value) == 1:
+			t.Priority = todoPriority(value)
+		case key == "project":
+			t.Project, err = url.QueryUnescape(value)
+		case key == "id":
+			t.Key, err = strconv.Atoi(value)
+		case key == "text":
+			var text string
+			text, err = url.QueryUnescape(value)
+			words = append(words, text)
+		default:
+			words = append(words, f)
+		}
+		if err != nil {
+			return t, err
+		}
+	}
+	t.Value = strings.Join(words, " ")
+	return t, nil
+}
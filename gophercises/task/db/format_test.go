@@ -0,0 +1,150 @@
+package db
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exampleTasks() []Task {
+	created := time.Date(2020, 1, 2, 0, 0, 0, 0, time.Local)
+	due := created.AddDate(0, 0, 7)
+	completed := created.AddDate(0, 0, 1)
+	return []Task{
+		{Key: 3, Value: "rotate certs", Priority: PriorityHigh, Due: &due, Tags: []string{"ops", "prod"},
+			Notes: "see the runbook: step 2", Every: "mon,thu", Created: created, Position: 1},
+		{Key: 7, Value: "buy milk", Created: created, Position: 2},
+		{Key: 5, Value: "file taxes", Priority: PriorityLow, Created: created, Position: 3, Completed: &completed},
+	}
+}
+
+func TestFormatsRoundTrip(t *testing.T) {
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		assert.Nil(t, WriteTasks(&buf, format, exampleTasks()), format)
+		tasks, err := ReadTasks(&buf, format)
+		assert.Nil(t, err, format)
+		want := exampleTasks()
+		if format == "todotxt" {
+			// todo.txt doesn't keep the position of completed tasks
+			want[2].Position = 0
+		}
+		if assert.Len(t, tasks, len(want), format) {
+			for i := range want {
+				assert.Equal(t, want[i].Key, tasks[i].Key, format)
+				assert.Equal(t, want[i].Value, tasks[i].Value, format)
+				assert.Equal(t, want[i].Priority, tasks[i].Priority, format)
+				assert.Equal(t, want[i].Tags, tasks[i].Tags, format)
+				assert.Equal(t, want[i].Notes, tasks[i].Notes, format)
+				assert.Equal(t, want[i].Every, tasks[i].Every, format)
+				assert.Equal(t, want[i].Position, tasks[i].Position, format)
+				assert.True(t, want[i].Created.Equal(tasks[i].Created), format)
+				assert.Equal(t, want[i].Due == nil, tasks[i].Due == nil, format)
+				if want[i].Due != nil {
+					assert.True(t, want[i].Due.Equal(*tasks[i].Due), format)
+				}
+				assert.Equal(t, want[i].Completed == nil, tasks[i].Completed == nil, format)
+				if want[i].Completed != nil {
+					assert.True(t, want[i].Completed.Equal(*tasks[i].Completed), format)
+				}
+			}
+		}
+	}
+}
+
+func TestFormatsKeepProjectAndMonthDay(t *testing.T) {
+	due := time.Date(2020, 2, 29, 0, 0, 0, 0, time.Local)
+	monthly := []Task{{Key: 1, Project: "home office", Value: "pay rent", Due: &due, Every: "month", MonthDay: 31}}
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		assert.Nil(t, WriteTasks(&buf, format, monthly), format)
+		tasks, err := ReadTasks(&buf, format)
+		assert.Nil(t, err, format)
+		if assert.Len(t, tasks, 1, format) {
+			assert.Equal(t, "home office", tasks[0].Project, format)
+			assert.Equal(t, "pay rent", tasks[0].Value, format)
+			assert.Equal(t, 31, tasks[0].MonthDay, format)
+		}
+	}
+}
+
+func TestTodoLine(t *testing.T) {
+	tasks := exampleTasks()
+	assert.Equal(t, "(A) 2020-01-02 rotate certs @ops @prod due:2020-01-09 rec:mon,thu notes:see+the+runbook%3A+step+2 id:3", todoLine(tasks[0]))
+	assert.Equal(t, "x 2020-01-03 2020-01-02 file taxes pri:C id:5", todoLine(tasks[2]))
+}
+
+func TestTodoTextRoundTrip(t *testing.T) {
+	created := time.Date(2020, 1, 2, 0, 0, 0, 0, time.Local)
+	for _, value := range []string{
+		"email @bob about due:friday",
+		"@bob",
+		"check rec:weekly and project:x id:4",
+		"x marks the spot",
+		"(A) is a grade",
+		"2020-01-01 was a Wednesday",
+		"notes:later pri:high",
+		"text:raw",
+		"spaced  out\tvalue",
+		"call mom +family",
+	} {
+		for _, task := range []Task{{Value: value}, {Value: value, Created: created, Priority: PriorityHigh}} {
+			tasks, err := ReadTasks(strings.NewReader(todoLine(task)+"\n"), "todotxt")
+			assert.Nil(t, err, value)
+			if assert.Len(t, tasks, 1, value) {
+				assert.Equal(t, value, tasks[0].Value)
+				assert.Empty(t, tasks[0].Tags, value)
+			}
+		}
+	}
+	assert.Equal(t, "call mom +family", todoLine(Task{Value: "call mom +family"}))
+}
+
+func TestReadTasks(t *testing.T) {
+	t.Run("it reads todo.txt written elsewhere", func(t *testing.T) {
+		tasks, err := ReadTasks(strings.NewReader("(D) call mom @phone +family\n\nx 2020-01-03 pay rent\n"), "todotxt")
+		assert.Nil(t, err)
+		assert.Equal(t, []Task{
+			{Value: "call mom +family", Priority: PriorityLow, Tags: []string{"phone"}, Position: 1},
+			{Value: "pay rent", Completed: tasks[1].Completed},
+		}, tasks)
+		assert.Equal(t, 3, tasks[1].Completed.Day())
+	})
+
+	t.Run("it reads csv columns by name", func(t *testing.T) {
+		tasks, err := ReadTasks(strings.NewReader("priority,value\nmedium,walk dog\n"), "csv")
+		assert.Nil(t, err)
+		assert.Equal(t, []Task{{Value: "walk dog", Priority: PriorityMedium}}, tasks)
+	})
+
+	t.Run("it fails on invalid input", func(t *testing.T) {
+		for format, input := range map[string]string{
+			"json":    "{",
+			"csv":     "value,priority\nx,urgent\n",
+			"todotxt": "x due:someday\n",
+			"xml":     "",
+		} {
+			_, err := ReadTasks(strings.NewReader(input), format)
+			assert.NotNil(t, err, format)
+		}
+	})
+
+	t.Run("it checks and normalizes recurrences", func(t *testing.T) {
+		_, err := ReadTasks(strings.NewReader(`[{"id":1,"value":"a"},{"id":2,"value":"b","every":"fortnight"}]`), "json")
+		assert.EqualError(t, err, `json task 2: invalid recurrence "fortnight", use day, week, month or weekdays such as mon,thu`)
+		_, err = ReadTasks(strings.NewReader("value,every\na,daily\nb,fortnight\n"), "csv")
+		assert.EqualError(t, err, `csv line 3: invalid recurrence "fortnight", use day, week, month or weekdays such as mon,thu`)
+		_, err = ReadTasks(strings.NewReader("b rec:fortnight\n"), "todotxt")
+		assert.NotNil(t, err)
+
+		tasks, err := ReadTasks(strings.NewReader(`[{"value":"a","every":"Thu, Mon"}]`), "json")
+		assert.Nil(t, err)
+		assert.Equal(t, "mon,thu", tasks[0].Every)
+		tasks, err = ReadTasks(strings.NewReader("value,every\na,Weekly\n"), "csv")
+		assert.Nil(t, err)
+		assert.Equal(t, "week", tasks[0].Every)
+	})
+}
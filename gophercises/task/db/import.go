@@ -0,0 +1,152 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/boltdb/bolt"
+)
+
+// ExportTasks returns the open tasks of the current project in the order
+// of the list followed by its completed ones, or those of every project
+// with all set, each with its Project.
+func ExportTasks(all bool) ([]Task, error) {
+	var tasks []Task
+	err := db.View(func(tx *bolt.Tx) error {
+		names := []string{project}
+		if all {
+			var err error
+			if names, err = projectNames(tx); err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			var open, done []Task
+			if b, _ := projectBucket(tx, name, taskBucket, false); b != nil {
+				open = decodeBucket(b)
+			}
+			if b, _ := projectBucket(tx, name, completedBucket, false); b != nil {
+				done = decodeBucket(b)
+			}
+			sort.SliceStable(open, func(i, j int) bool { return open[i].Position < open[j].Position })
+			sort.SliceStable(done, func(i, j int) bool {
+				return done[i].Completed != nil && done[j].Completed != nil && done[i].Completed.Before(*done[j].Completed)
+			})
+			for _, task := range append(open, done...) {
+				task.Project = name
+				tasks = append(tasks, task)
+			}
+		}
+		return nil
+	})
+	return tasks, err
+}
+
+// ImportTasks adds tasks to their project, or to the current one for
+// tasks without a project, in one transaction, the completed ones to its
+// completed tasks. A task keeps its key while no task has it, so a backup
+// is restored as it was. A task whose key already holds a task with the
+// same text in its project is skipped, so importing a backup again doesn't
+// duplicate it; other tasks are given a new key, so importing never
+// overwrites a task. Open tasks are put after the existing ones in the
+// order of their positions. It returns how many tasks were added.
+func ImportTasks(tasks []Task) (int, error) {
+	t := now()
+	var open, done []Task
+	for _, task := range tasks {
+		if task.Completed == nil {
+			open = append(open, task)
+		} else {
+			done = append(done, task)
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool { return open[i].Position < open[j].Position })
+	added := 0
+	err := db.Update(func(tx *bolt.Tx) error {
+		seq := tx.Bucket(taskBucket)
+		last := make(map[string]int)
+		for _, task := range append(open, done...) {
+			name := task.Project
+			if name == "" {
+				name = project
+			}
+			bucket := taskBucket
+			if task.Completed != nil {
+				bucket = completedBucket
+			}
+			b, err := projectBucket(tx, name, bucket, true)
+			if err != nil {
+				return err
+			}
+			if task.Created.IsZero() {
+				task.Created = t
+			}
+			key := task.Key
+			if key > 0 {
+				have, in, err := findTask(tx, key)
+				if err != nil {
+					return err
+				}
+				switch {
+				case in == "":
+					// the key is free, keep the sequence past it
+					if uint64(key) > seq.Sequence() {
+						if err := seq.SetSequence(uint64(key)); err != nil {
+							return err
+						}
+					}
+				case in == name && have.Value == task.Value:
+					continue
+				default:
+					key = 0
+				}
+			}
+			if key <= 0 {
+				id, _ := seq.NextSequence()
+				key = int(id)
+			}
+			if task.Completed == nil {
+				if _, ok := last[name]; !ok {
+					if last[name], err = lastPosition(b); err != nil {
+						return err
+					}
+				}
+				last[name]++
+				task.Position = last[name]
+			}
+			data, err := encodeTask(task)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(key), data); err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// findTask looks for the open or completed task of given key in every
+// project, giving the name of its project or "" when there is none.
+func findTask(tx *bolt.Tx, key int) (Task, string, error) {
+	names, err := projectNames(tx)
+	if err != nil {
+		return Task{}, "", err
+	}
+	for _, name := range names {
+		for _, bucket := range [][]byte{taskBucket, completedBucket} {
+			b, _ := projectBucket(tx, name, bucket, false)
+			if b == nil {
+				continue
+			}
+			if v := b.Get(itob(key)); v != nil {
+				return decodeTask(key, v), name, nil
+			}
+		}
+	}
+	return Task{}, "", nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportTasks(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "import_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+	Init(dbPath)
+
+	existing, _ := CreateTask(Task{Value: "existing"})
+	added, err := ImportTasks(exampleTasks())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, added)
+
+	tasks, _ := AllTasks()
+	if assert.Len(t, tasks, 3) {
+		assert.Equal(t, existing, tasks[0].Key)
+		assert.Equal(t, "rotate certs", tasks[1].Value)
+		assert.Equal(t, 2, tasks[1].Position)
+		assert.Equal(t, "buy milk", tasks[2].Value)
+		assert.Equal(t, 3, tasks[2].Position)
+		assert.Equal(t, 3, tasks[1].Key)
+	}
+	done, _ := CompletedTasks()
+	if assert.Len(t, done, 1) {
+		assert.Equal(t, "file taxes", done[0].Value)
+		assert.NotNil(t, done[0].Completed)
+	}
+
+	t.Run("it keeps the sequence past the imported keys", func(t *testing.T) {
+		key, _ := CreateTask(Task{Value: "after import"})
+		assert.Equal(t, 8, key)
+	})
+
+	t.Run("it skips tasks already there", func(t *testing.T) {
+		added, err := ImportTasks(exampleTasks())
+		assert.Nil(t, err)
+		assert.Equal(t, 0, added)
+		tasks, _ := AllTasks()
+		assert.Len(t, tasks, 4)
+	})
+
+	t.Run("it gives a new key to another task", func(t *testing.T) {
+		added, _ := ImportTasks([]Task{{Key: 3, Value: "other task"}})
+		assert.Equal(t, 1, added)
+		tasks, _ := AllTasks()
+		if assert.Len(t, tasks, 5) {
+			assert.Equal(t, "other task", tasks[4].Value)
+			assert.Equal(t, 9, tasks[4].Key)
+		}
+	})
+
+	t.Run("it restores tasks to their project", func(t *testing.T) {
+		defer UseProject(DefaultProject)
+		added, _ := ImportTasks([]Task{{Key: 20, Project: "work", Value: "ship it"}})
+		assert.Equal(t, 1, added)
+		UseProject("work")
+		tasks, _ := AllTasks()
+		if assert.Len(t, tasks, 1) {
+			assert.Equal(t, 20, tasks[0].Key)
+			assert.Equal(t, 1, tasks[0].Position)
+		}
+	})
+
+	t.Run("it exports the current or every project", func(t *testing.T) {
+		tasks, err := ExportTasks(false)
+		assert.Nil(t, err)
+		assert.Len(t, tasks, 6)
+		assert.Equal(t, "existing", tasks[0].Value)
+		assert.Equal(t, DefaultProject, tasks[0].Project)
+		assert.Equal(t, "file taxes", tasks[5].Value)
+
+		tasks, _ = ExportTasks(true)
+		if assert.Len(t, tasks, 7) {
+			assert.Equal(t, "work", tasks[6].Project)
+			assert.Equal(t, "ship it", tasks[6].Value)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/boltdb/bolt"
+)
+
+var projectsBucket = []byte("projects")
+
+// DefaultProject is the project used unless another one is chosen. Its
+// tasks are kept in the top-level buckets, where every task lived before
+// there were projects; other projects are nested buckets of "projects".
+const DefaultProject = "default"
+
+// project is the project the other functions work on.
+var project = DefaultProject
+
+// Project is a task list along with its number of open and completed
+// tasks.
+type Project struct {
+	Name string
+	Open int
+	Done int
+}
+
+// UseProject makes the other functions work on the tasks of project
+// name, which is created when a task is first added to it.
+func UseProject(name string) error {
+	if name == "" {
+		return errors.New("project name can't be empty")
+	}
+	project = name
+	return nil
+}
+
+// CurrentProject returns the name of the project in use.
+func CurrentProject() string {
+	return project
+}
+
+// projectBucket returns bucket, taskBucket or completedBucket, of project
+// name. When create isn't set it is nil for a project without one yet.
+func projectBucket(tx *bolt.Tx, name string, bucket []byte, create bool) (*bolt.Bucket, error) {
+	if name == DefaultProject {
+		return tx.Bucket(bucket), nil
+	}
+	projects := tx.Bucket(projectsBucket)
+	if !create {
+		p := projects.Bucket([]byte(name))
+		if p == nil {
+			return nil, nil
+		}
+		return p.Bucket(bucket), nil
+	}
+	p, err := projects.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return p.CreateBucketIfNotExists(bucket)
+}
+
+// Projects returns every project, the default one first and the others
+// by name.
+func Projects() ([]Project, error) {
+	var projects []Project
+	err := db.View(func(tx *bolt.Tx) error {
+		names, err := projectNames(tx)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			p := Project{Name: name}
+			if b, _ := projectBucket(tx, name, taskBucket, false); b != nil {
+				p.Open = b.Stats().KeyN
+			}
+			if b, _ := projectBucket(tx, name, completedBucket, false); b != nil {
+				p.Done = b.Stats().KeyN
+			}
+			projects = append(projects, p)
+		}
+		return nil
+	})
+	return projects, err
+}
+
+// projectNames gives the names of every project, the default one first
+// and the others sorted.
+func projectNames(tx *bolt.Tx) ([]string, error) {
+	var names []string
+	err := tx.Bucket(projectsBucket).ForEach(func(k, v []byte) error {
+		if v == nil {
+			names = append(names, string(k))
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return append([]string{DefaultProject}, names...), err
+}
+
+// MoveToProject moves the open task of given key from the current project
+// to the end of project to, in a single transaction. It keeps its key,
+// which is unique across projects.
+func MoveToProject(key int, to string) error {
+	if to == "" {
+		return errors.New("project name can't be empty")
+	}
+	if to == project {
+		return nil
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		src, err := projectBucket(tx, project, taskBucket, false)
+		if err != nil {
+			return err
+		}
+		if src == nil || src.Get(itob(key)) == nil {
+			return ErrNoTask
+		}
+		dst, err := projectBucket(tx, to, taskBucket, true)
+		if err != nil {
+			return err
+		}
+		task := decodeTask(key, src.Get(itob(key)))
+		last, err := lastPosition(dst)
+		if err != nil {
+			return err
+		}
+		task.Position = last + 1
+		data, err := encodeTask(task)
+		if err != nil {
+			return err
+		}
+		if err := dst.Put(itob(key), data); err != nil {
+			return err
+		}
+		return src.Delete(itob(key))
+	})
+}
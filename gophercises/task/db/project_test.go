@@ -0,0 +1,63 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjects(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "project_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+	Init(dbPath)
+	defer UseProject(DefaultProject)
+
+	inbox, _ := CreateTask(Task{Value: "inbox task"})
+	assert.Nil(t, UseProject("infra"))
+	patch, _ := CreateTask(Task{Value: "patch servers"})
+	certs, _ := CreateTask(Task{Value: "rotate certs"})
+	assert.Nil(t, CompleteTask(certs))
+
+	t.Run("it keeps the tasks of each project apart", func(t *testing.T) {
+		tasks, _ := AllTasks()
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, "patch servers", tasks[0].Value)
+		assert.NotEqual(t, inbox, patch)
+
+		UseProject(DefaultProject)
+		tasks, _ = AllTasks()
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, "inbox task", tasks[0].Value)
+
+		UseProject("empty")
+		tasks, err := AllTasks()
+		assert.Nil(t, err)
+		assert.Empty(t, tasks)
+		assert.Equal(t, ErrNoTask, CompleteTask(inbox))
+		assert.Nil(t, DeleteTask(inbox))
+	})
+
+	t.Run("it counts open and done tasks per project", func(t *testing.T) {
+		projects, err := Projects()
+		assert.Nil(t, err)
+		assert.Equal(t, []Project{{Name: DefaultProject, Open: 1}, {Name: "infra", Open: 1, Done: 1}}, projects)
+	})
+
+	t.Run("it moves tasks between projects", func(t *testing.T) {
+		UseProject(DefaultProject)
+		assert.Nil(t, MoveToProject(inbox, "infra"))
+		tasks, _ := AllTasks()
+		assert.Empty(t, tasks)
+
+		UseProject("infra")
+		tasks, _ = AllTasks()
+		assert.Equal(t, []int{patch, inbox}, []int{tasks[0].Key, tasks[1].Key})
+		assert.Equal(t, ErrNoTask, MoveToProject(certs, DefaultProject))
+		assert.NotNil(t, UseProject(""))
+	})
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Recurrence is when a repeating task comes back: every day, week or
+// month after its due date, or on the next of some weekdays. A monthly
+// rule falls on Day of the month, or on the day it is counted from when
+// Day is 0.
+type Recurrence struct {
+	Unit     string
+	Weekdays []time.Weekday
+	Day      int
+}
+
+var weekdayNames = map[string]time.Weekday{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		weekdayNames[name] = d
+		weekdayNames[name[:3]] = d
+	}
+}
+
+// ParseRecurrence parses "day", "week", "month", their "daily",
+// "weekly" and "monthly" forms, or a comma separated list of weekdays
+// such as "mon,thu".
+func ParseRecurrence(s string) (Recurrence, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "day", "daily":
+		return Recurrence{Unit: "day"}, nil
+	case "week", "weekly":
+		return Recurrence{Unit: "week"}, nil
+	case "month", "monthly":
+		return Recurrence{Unit: "month"}, nil
+	}
+	var r Recurrence
+	seen := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(s, ",") {
+		d, ok := weekdayNames[strings.TrimSpace(name)]
+		if !ok {
+			return Recurrence{}, fmt.Errorf("invalid recurrence %q, use day, week, month or weekdays such as mon,thu", s)
+		}
+		if !seen[d] {
+			seen[d] = true
+			r.Weekdays = append(r.Weekdays, d)
+		}
+	}
+	sort.Slice(r.Weekdays, func(i, j int) bool { return r.Weekdays[i] < r.Weekdays[j] })
+	return r, nil
+}
+
+// String gives the rule in the form ParseRecurrence reads.
+func (r Recurrence) String() string {
+	if r.Unit != "" {
+		return r.Unit
+	}
+	var names []string
+	for _, d := range r.Weekdays {
+		names = append(names, strings.ToLower(d.String()[:3]))
+	}
+	return strings.Join(names, ",")
+}
+
+// Next gives the first day after day that the rule falls on. A monthly
+// rule keeps its day of the month, or the last day of shorter months.
+func (r Recurrence) Next(day time.Time) time.Time {
+	switch r.Unit {
+	case "day":
+		return day.AddDate(0, 0, 1)
+	case "week":
+		return day.AddDate(0, 0, 7)
+	case "month":
+		monthDay := r.Day
+		if monthDay == 0 {
+			monthDay = day.Day()
+		}
+		next := time.Date(day.Year(), day.Month()+1, 1, day.Hour(), day.Minute(), day.Second(), day.Nanosecond(), day.Location())
+		last := next.AddDate(0, 1, -1).Day()
+		if monthDay < last {
+			last = monthDay
+		}
+		return next.AddDate(0, 0, last-1)
+	}
+	for i := 1; i <= 7; i++ {
+		next := day.AddDate(0, 0, i)
+		for _, d := range r.Weekdays {
+			if next.Weekday() == d {
+				return next
+			}
+		}
+	}
+	return day
+}
+
+// recurrence parses the rule of t, anchoring a monthly one to its day of
+// the month.
+func (t Task) recurrence() (Recurrence, error) {
+	r, err := ParseRecurrence(t.Every)
+	if r.Unit == "month" {
+		r.Day = t.monthDay()
+	}
+	return r, err
+}
+
+// monthDay gives the day of the month a monthly task recurs on: MonthDay,
+// else the day it is due.
+func (t Task) monthDay() int {
+	if t.MonthDay != 0 || t.Due == nil {
+		return t.MonthDay
+	}
+	return t.Due.Day()
+}
+
+// NextDue gives the due date of the occurrence that follows t when it is
+// completed at now: the first day of its rule after its due date, skipping
+// days before today so a late task doesn't come back overdue.
+func (t Task) NextDue(now time.Time) (time.Time, error) {
+	r, err := t.recurrence()
+	if err != nil {
+		return time.Time{}, err
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	due := today
+	if t.Due != nil {
+		due = *t.Due
+	}
+	due = r.Next(due)
+	for due.Before(today) {
+		due = r.Next(due)
+	}
+	return due, nil
+}
+
+// Upcoming gives the due dates of the occurrences of a recurring task
+// that follow its current one, from the day of now up to and including
+// until.
+func (t Task) Upcoming(now, until time.Time) []time.Time {
+	r, err := t.recurrence()
+	if err != nil || t.Due == nil {
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var dates []time.Time
+	for due := r.Next(*t.Due); !due.After(until); due = r.Next(due) {
+		if !due.Before(today) {
+			dates = append(dates, due)
+		}
+	}
+	return dates
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	for s, want := range map[string]string{
+		"day":             "day",
+		"Weekly":          "week",
+		"monthly":         "month",
+		"thu,mon":         "mon,thu",
+		"Friday, fri,sun": "sun,fri",
+	} {
+		r, err := ParseRecurrence(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, want, r.String(), s)
+	}
+	for _, s := range []string{"", "fortnight", "mon,someday"} {
+		_, err := ParseRecurrence(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2020, m, d, 0, 0, 0, 0, time.UTC) }
+	next := func(rule string, from time.Time) time.Time {
+		r, _ := ParseRecurrence(rule)
+		return r.Next(from)
+	}
+
+	assert.Equal(t, day(1, 3), next("day", day(1, 2)))
+	assert.Equal(t, day(1, 9), next("week", day(1, 2)))
+	assert.Equal(t, day(2, 29), next("month", day(1, 31)))
+	assert.Equal(t, day(4, 30), next("month", day(3, 31)))
+	assert.Equal(t, day(3, 31), Recurrence{Unit: "month", Day: 31}.Next(day(2, 29)))
+	// 2020-01-02 is a Thursday
+	assert.Equal(t, day(1, 6), next("mon,thu", day(1, 2)))
+	assert.Equal(t, day(1, 9), next("thu", day(1, 2)))
+}
+
+func TestNextDue(t *testing.T) {
+	now := time.Date(2020, 1, 10, 15, 0, 0, 0, time.UTC)
+	due := time.Date(2020, 1, 9, 0, 0, 0, 0, time.UTC)
+	longAgo := time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	next, err := Task{Every: "week", Due: &due}.NextDue(now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, 1, 16, 0, 0, 0, 0, time.UTC), next)
+
+	next, _ = Task{Every: "day", Due: &longAgo}.NextDue(now)
+	assert.Equal(t, time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC), next)
+
+	next, _ = Task{Every: "day"}.NextDue(now)
+	assert.Equal(t, time.Date(2020, 1, 11, 0, 0, 0, 0, time.UTC), next)
+
+	_, err = Task{}.NextDue(now)
+	assert.NotNil(t, err)
+
+	feb := time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)
+	next, _ = Task{Every: "month", Due: &feb, MonthDay: 31}.NextDue(feb)
+	assert.Equal(t, time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC), next)
+}
+
+func TestUpcoming(t *testing.T) {
+	now := time.Date(2020, 1, 10, 15, 0, 0, 0, time.UTC)
+	due := time.Date(2020, 1, 8, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	upcoming := Task{Every: "day", Due: &due}.Upcoming(now, now.AddDate(0, 0, 3))
+	assert.Equal(t, []time.Time{day(10), day(11), day(12), day(13)}, upcoming)
+	assert.Empty(t, Task{Every: "month", Due: &due}.Upcoming(now, now.AddDate(0, 0, 7)))
+
+	endOfJan := day(31)
+	monthly := Task{Every: "month", Due: &endOfJan}.Upcoming(now, time.Date(2020, 5, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, []time.Time{
+		time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 4, 30, 0, 0, 0, 0, time.UTC), time.Date(2020, 5, 31, 0, 0, 0, 0, time.UTC),
+	}, monthly)
+	assert.Empty(t, Task{Due: &due}.Upcoming(now, now.AddDate(0, 0, 7)))
+}
+
+func TestCompleteRecurringTask(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "recurring_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+	Init(dbPath)
+	defer func() { now = time.Now }()
+	t0 := time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC)
+	now = func() time.Time { return t0 }
+	due := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	key, _ := CreateTask(Task{Value: "water plants", Every: "mon,thu", Due: &due})
+	assert.Nil(t, CompleteTask(key))
+
+	tasks, _ := AllTasks()
+	if assert.Len(t, tasks, 1) {
+		next := tasks[0]
+		assert.NotEqual(t, key, next.Key)
+		assert.Equal(t, "water plants", next.Value)
+		assert.Equal(t, time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), *next.Due)
+		assert.Nil(t, next.Completed)
+	}
+	done, _ := CompletedTasks()
+	if assert.Len(t, done, 1) {
+		assert.Equal(t, key, done[0].Key)
+		assert.Equal(t, due, *done[0].Due)
+	}
+}
+
+func TestCompleteMonthlyTask(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "monthly_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+	Init(dbPath)
+	defer func() { now = time.Now }()
+	due := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return due }
+
+	key, _ := CreateTask(Task{Value: "pay rent", Every: "month", Due: &due})
+	for _, want := range []time.Time{
+		time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC),
+	} {
+		assert.Nil(t, CompleteTask(key))
+		tasks, _ := AllTasks()
+		if !assert.Len(t, tasks, 1) {
+			return
+		}
+		assert.Equal(t, want, *tasks[0].Due)
+		assert.Equal(t, 31, tasks[0].MonthDay)
+		key = tasks[0].Key
+	}
+}
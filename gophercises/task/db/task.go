@@ -0,0 +1,112 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Priority is how urgent a task is. The zero value means no priority.
+type Priority int
+
+// Priorities from least to most urgent.
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = []string{"", "low", "medium", "high"}
+
+// ParsePriority parses "low", "medium", "high" or "" for no priority.
+func ParsePriority(s string) (Priority, error) {
+	for p, name := range priorityNames {
+		if strings.EqualFold(s, name) {
+			return Priority(p), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("invalid priority %q, use low, medium or high", s)
+}
+
+func (p Priority) String() string {
+	if p < 0 || int(p) >= len(priorityNames) {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// MarshalText encodes the priority by name.
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a priority name.
+func (p *Priority) UnmarshalText(text []byte) error {
+	parsed, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// Task is a task along with its metadata. Key is the bolt key of the
+// task, which is not part of the stored record, and neither is Project,
+// only set on exported and imported tasks as they may come from several
+// projects.
+type Task struct {
+	Key      int        `json:"-"`
+	Project  string     `json:"-"`
+	Value    string     `json:"value"`
+	Priority Priority   `json:"priority,omitempty"`
+	Due      *time.Time `json:"due,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	Notes    string     `json:"notes,omitempty"`
+	// Every is the recurrence rule of a repeating task, see
+	// ParseRecurrence
+	Every string `json:"every,omitempty"`
+	// MonthDay is the day of the month a monthly task recurs on, kept
+	// from its first due date so a short month doesn't move it
+	MonthDay int       `json:"month_day,omitempty"`
+	Created  time.Time `json:"created"`
+	// Position orders the tasks in the list; tasks written before it
+	// existed come first, in the order of their keys
+	Position int `json:"position,omitempty"`
+	// Completed is set for completed tasks
+	Completed *time.Time `json:"completed,omitempty"`
+}
+
+// Overdue reports whether the task was due on a day before the day of
+// now.
+func (t Task) Overdue(now time.Time) bool {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return t.Due != nil && t.Due.Before(today)
+}
+
+// HasTag reports whether the task is tagged with tag.
+func (t Task) HasTag(tag string) bool {
+	for _, have := range t.Tags {
+		if strings.EqualFold(have, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeTask(t Task) ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// decodeTask decodes a stored record. Records are JSON since schema
+// version 1; plain string values written before are read as the task
+// text, in case a database is read before it was migrated.
+func decodeTask(key int, data []byte) Task {
+	var t Task
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Task{Key: key, Value: string(data)}
+	}
+	t.Key = key
+	return t
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	p, err := ParsePriority("High")
+	assert.Nil(t, err)
+	assert.Equal(t, PriorityHigh, p)
+	p, err = ParsePriority("")
+	assert.Nil(t, err)
+	assert.Equal(t, PriorityNone, p)
+	_, err = ParsePriority("urgent")
+	assert.NotNil(t, err)
+
+	data, _ := json.Marshal(Task{Value: "x", Priority: PriorityLow})
+	assert.Contains(t, string(data), `"priority":"low"`)
+	var task Task
+	assert.Nil(t, json.Unmarshal(data, &task))
+	assert.Equal(t, PriorityLow, task.Priority)
+}
+
+func TestTask(t *testing.T) {
+	now := time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC)
+	today := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+
+	assert.False(t, Task{}.Overdue(now))
+	assert.False(t, Task{Due: &today}.Overdue(now))
+	assert.True(t, Task{Due: &yesterday}.Overdue(now))
+
+	task := Task{Tags: []string{"Ops", "home"}}
+	assert.True(t, task.HasTag("ops"))
+	assert.False(t, task.HasTag("work"))
+
+	assert.Equal(t, Task{Key: 3, Value: "plain"}, decodeTask(3, []byte("plain")))
+	assert.Equal(t, Task{Key: 3, Value: "rec", Priority: PriorityMedium}, decodeTask(3, []byte(`{"value":"rec","priority":"medium","created":"0001-01-01T00:00:00Z"}`)))
+}
@@ -2,27 +2,41 @@ package db
 
 import (
 	"encoding/binary"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/boltdb/bolt"
 )
 
 var taskBucket = []byte("tasks")
+var completedBucket = []byte("completed")
+var metaBucket = []byte("meta")
+var schemaKey = []byte("schema")
 var db *bolt.DB
 var NewCreateTask = CreateTask
 var NewDeleteTask = DeleteTask
 var NewAllTasks = AllTasks
+var NewCompleteTask = CompleteTask
+var NewCompletedTasks = CompletedTasks
+var NewReopenTask = ReopenTask
+var NewUpdateTask = UpdateTask
+var NewMoveTask = MoveTask
+var NewProjects = Projects
+var NewMoveToProject = MoveToProject
+var NewImportTasks = ImportTasks
+var NewExportTasks = ExportTasks
 
 var newDbView = dbView
 var newDbUpdate = dbUpdate
+var now = time.Now
 
-// Task is a struct which defines key value parameters
-type Task struct {
-	Key   int
-	Value string
-}
+// schemaVersion is the layout of the database written by this version:
+// 1 stores tasks as JSON records rather than plain strings.
+const schemaVersion = 1
 
-// Init opens db and create taskbucket if it is not exists.
+// Init opens db and create taskbucket if it is not exists. Databases
+// written by older versions are migrated to the current schema.
 func Init(dbPath string) error {
 	var err error
 	db, err = bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
@@ -30,25 +44,84 @@ func Init(dbPath string) error {
 		return err
 	}
 	return db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(taskBucket)
-		return err
+		for _, bucket := range [][]byte{taskBucket, completedBucket, projectsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return migrate(tx)
 	})
 }
 
-func dbUpdate(task string) (int, error) {
+// migrate upgrades the database to schemaVersion. Databases without a
+// recorded version hold plain string tasks.
+func migrate(tx *bolt.Tx) error {
+	meta, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+	version := 0
+	if v := meta.Get(schemaKey); v != nil {
+		version = btoi(v)
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("the task database has schema %d, newer than this version of task knows", version)
+	}
+	if version < 1 {
+		b := tx.Bucket(taskBucket)
+		records := make(map[int][]byte)
+		err := b.ForEach(func(k, v []byte) error {
+			data, err := encodeTask(Task{Value: string(v)})
+			records[btoi(k)] = data
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for key, data := range records {
+			if err := b.Put(itob(key), data); err != nil {
+				return err
+			}
+		}
+	}
+	if version == schemaVersion {
+		return nil
+	}
+	return meta.Put(schemaKey, itob(schemaVersion))
+}
+
+func dbUpdate(task Task) (int, error) {
 	var id int
 	err := db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(taskBucket)
-		id64, _ := b.NextSequence()
+		// keys come from the sequence of the default project, so they are
+		// unique across projects
+		id64, _ := tx.Bucket(taskBucket).NextSequence()
 		id = int(id64)
 		key := itob(id)
-		return b.Put(key, []byte(task))
+		b, err := projectBucket(tx, project, taskBucket, true)
+		if err != nil {
+			return err
+		}
+		last, err := lastPosition(b)
+		if err != nil {
+			return err
+		}
+		task.Position = last + 1
+		data, err := encodeTask(task)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
 	})
 	return id, err
 }
 
-// CreateTask creates new task
-func CreateTask(task string) (int, error) {
+// CreateTask creates new task. Its Key is ignored; the key given to the
+// task is returned.
+func CreateTask(task Task) (int, error) {
+	if task.Created.IsZero() {
+		task.Created = now()
+	}
 	id, err := newDbUpdate(task)
 	if err != nil {
 		return -1, err
@@ -57,21 +130,35 @@ func CreateTask(task string) (int, error) {
 }
 
 func dbView() ([]Task, error) {
+	tasks, err := readBucket(taskBucket)
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Position < tasks[j].Position })
+	return tasks, err
+}
+
+// readBucket gives the tasks in bucket of the current project in key
+// order.
+func readBucket(bucket []byte) ([]Task, error) {
 	var tasks []Task
 	err := db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(taskBucket)
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			tasks = append(tasks, Task{
-				Key:   btoi(k),
-				Value: string(v),
-			})
+		b, err := projectBucket(tx, project, bucket, false)
+		if b == nil {
+			return err
 		}
+		tasks = decodeBucket(b)
 		return nil
 	})
 	return tasks, err
 }
 
+func decodeBucket(b *bolt.Bucket) []Task {
+	var tasks []Task
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		tasks = append(tasks, decodeTask(btoi(k), v))
+	}
+	return tasks
+}
+
 // AllTasks returns all tasks
 func AllTasks() ([]Task, error) {
 	tasks, err := newDbView()
@@ -84,7 +171,10 @@ func AllTasks() ([]Task, error) {
 // DeleteTask deletes task of given key
 func DeleteTask(key int) error {
 	return db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(taskBucket)
+		b, err := projectBucket(tx, project, taskBucket, false)
+		if b == nil {
+			return err
+		}
 		return b.Delete(itob(key))
 	})
 }
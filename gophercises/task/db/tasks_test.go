@@ -2,9 +2,11 @@ package db
 
 import (
 	"errors"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/boltdb/bolt"
 	"github.com/mitchellh/go-homedir"
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +19,7 @@ func (f *fakeDB) dbView() ([]Task, error) {
 	return nil, f.err
 }
 
-func (f *fakeDB) dbUpdate(task string) (int, error) {
+func (f *fakeDB) dbUpdate(task Task) (int, error) {
 	return 0, f.err
 }
 
@@ -46,15 +48,16 @@ func TestCreateTask(t *testing.T) {
 	home, _ := homedir.Dir()
 	dbPath := filepath.Join(home, "test_create.db")
 	Init(dbPath)
+	defer func() { newDbUpdate = dbUpdate }()
 	t.Run("it creates a new task", func(t *testing.T) {
-		id, _ := CreateTask("test_key")
+		id, _ := CreateTask(Task{Value: "test_key"})
 		assert.NotNil(t, id)
 	})
 
 	t.Run("it returns error if boltdb failed to create task", func(t *testing.T) {
 		f := &fakeDB{err: errors.New("Failed")}
 		newDbUpdate = f.dbUpdate
-		id, err := CreateTask("demo_key")
+		id, err := CreateTask(Task{Value: "demo_key"})
 		assert.NotNil(t, err)
 		assert.Equal(t, id, -1)
 	})
@@ -64,9 +67,16 @@ func TestAllTasks(t *testing.T) {
 	home, _ := homedir.Dir()
 	dbPath := filepath.Join(home, "all_tasks.db")
 	Init(dbPath)
+	defer func() { newDbView = dbView }()
 	t.Run("it gives all tasks", func(t *testing.T) {
+		CreateTask(Task{Value: "test_key", Priority: PriorityHigh, Tags: []string{"ops"}})
 		tasks, _ := AllTasks()
 		assert.NotEmpty(t, tasks)
+		last := tasks[len(tasks)-1]
+		assert.Equal(t, "test_key", last.Value)
+		assert.Equal(t, PriorityHigh, last.Priority)
+		assert.Equal(t, []string{"ops"}, last.Tags)
+		assert.False(t, last.Created.IsZero())
 	})
 
 	t.Run("it returns error if boltdb failed to give tasks", func(t *testing.T) {
@@ -90,3 +100,46 @@ func TestDeleteTask(t *testing.T) {
 
 	})
 }
+
+func TestMigrate(t *testing.T) {
+	home, _ := homedir.Dir()
+	dbPath := filepath.Join(home, "migrate_tasks.db")
+	os.Remove(dbPath)
+	defer os.Remove(dbPath)
+
+	old, err := bolt.Open(dbPath, 0600, nil)
+	assert.Nil(t, err)
+	old.Update(func(tx *bolt.Tx) error {
+		b, _ := tx.CreateBucket(taskBucket)
+		b.Put(itob(1), []byte("plain task"))
+		return b.Put(itob(2), []byte(`{"value":"looks like json"`))
+	})
+	old.Close()
+
+	t.Run("it converts plain string tasks to records", func(t *testing.T) {
+		assert.Nil(t, Init(dbPath))
+		db.View(func(tx *bolt.Tx) error {
+			assert.Equal(t, `{"value":"plain task","created":"0001-01-01T00:00:00Z"}`, string(tx.Bucket(taskBucket).Get(itob(1))))
+			return nil
+		})
+		tasks, err := AllTasks()
+		assert.Nil(t, err)
+		assert.Equal(t, []Task{{Key: 1, Value: "plain task"}, {Key: 2, Value: `{"value":"looks like json"`}}, tasks)
+	})
+
+	t.Run("it migrates only once", func(t *testing.T) {
+		db.Close()
+		assert.Nil(t, Init(dbPath))
+		tasks, _ := AllTasks()
+		assert.Equal(t, "plain task", tasks[0].Value)
+	})
+
+	t.Run("it refuses a newer schema", func(t *testing.T) {
+		db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(metaBucket).Put(schemaKey, itob(schemaVersion+1))
+		})
+		db.Close()
+		assert.NotNil(t, Init(dbPath))
+		db.Close()
+	})
+}